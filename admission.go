@@ -0,0 +1,28 @@
+package cachestore
+
+import "sync/atomic"
+
+type admissionHook func(key string, value any, opt *SetOptions) bool
+
+var admission atomic.Pointer[admissionHook]
+
+// SetAdmissionHook makes every Set call fn first and drop the write when fn returns false,
+// the existing entry is kept. opt may be nil, nil fn removes the hook.
+func SetAdmissionHook(fn func(key string, value any, opt *SetOptions) bool) {
+	if fn == nil {
+		admission.Store(nil)
+		return
+	}
+	h := admissionHook(fn)
+	admission.Store(&h)
+}
+
+func admit(key string, value any, opt *SetOptions) bool {
+	if opt != nil && opt.Admit != nil && !opt.Admit(key, value, opt) {
+		return false
+	}
+	if h := admission.Load(); h != nil {
+		return (*h)(key, value, opt)
+	}
+	return true
+}
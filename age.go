@@ -0,0 +1,64 @@
+package cachestore
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+var ageBuckets = []time.Duration{
+	time.Second,
+	10 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	time.Hour,
+	6 * time.Hour,
+	24 * time.Hour,
+}
+
+type ageCounter [9]uint64 // len(ageBuckets) + 1
+
+func (c *ageCounter) record(it *item) {
+	age := time.Since(it.createdAt)
+	i := 0
+	for i < len(ageBuckets) && age > ageBuckets[i] {
+		i++
+	}
+	atomic.AddUint64(&c[i], 1)
+}
+
+func (c *ageCounter) histogram() AgeHistogram {
+	h := AgeHistogram{
+		Buckets: append([]time.Duration(nil), ageBuckets...),
+		Counts:  make([]uint64, len(c)),
+	}
+	for i := range c {
+		h.Counts[i] = atomic.LoadUint64(&c[i])
+	}
+	return h
+}
+
+var (
+	expiredAges ageCounter
+	deletedAges ageCounter
+)
+
+// AgeHistogram counts entries by age when they were removed,
+// Counts[i] counts ages up to Buckets[i], the last count is older than every bucket.
+type AgeHistogram struct {
+	Buckets []time.Duration
+	Counts  []uint64
+}
+
+type AgeStats struct {
+	Expired AgeHistogram // removed by GC after expired
+	Deleted AgeHistogram // removed by Delete, DeleteTag or Clear
+}
+
+// Ages returns age distribution of entries removed since the process started
+func Ages() AgeStats {
+	return AgeStats{
+		Expired: expiredAges.histogram(),
+		Deleted: deletedAges.histogram(),
+	}
+}
@@ -0,0 +1,95 @@
+package cachestore
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type AuditOp string
+
+const (
+	OpSet       AuditOp = "set"
+	OpGet       AuditOp = "get"
+	OpGetStale  AuditOp = "get_stale"
+	OpGetOrSet  AuditOp = "get_or_set"
+	OpDelete    AuditOp = "delete"
+	OpDeleteTag AuditOp = "delete_tag"
+	OpClear     AuditOp = "clear"
+)
+
+type AuditEntry struct {
+	Time  time.Time
+	Op    AuditOp
+	Key   string            // tag for OpDeleteTag, prefix for OpClear
+	Hit   bool              // found for gets, existed for deletes
+	Label string            // see WithLabel
+	Meta  map[string]string // see WithMeta
+}
+
+var (
+	auditEnabled uint32
+	auditMu      sync.Mutex
+	auditLog     []AuditEntry
+	auditNext    int
+	auditFull    bool
+)
+
+// SetAuditLog keeps the last n operations in the audit log, zero disables the audit log
+func SetAuditLog(n int) {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	if n <= 0 {
+		atomic.StoreUint32(&auditEnabled, 0)
+		auditLog = nil
+	} else {
+		auditLog = make([]AuditEntry, n)
+		atomic.StoreUint32(&auditEnabled, 1)
+	}
+	auditNext = 0
+	auditFull = false
+}
+
+func audit(op AuditOp, key string, hit bool, opt *SetOptions) {
+	if atomic.LoadUint32(&auditEnabled) == 0 {
+		return
+	}
+
+	e := AuditEntry{
+		Time: time.Now(),
+		Op:   op,
+		Key:  key,
+		Hit:  hit,
+	}
+	if opt != nil {
+		e.Label = opt.Label
+		e.Meta = opt.Meta
+	}
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	if len(auditLog) == 0 {
+		return
+	}
+	auditLog[auditNext] = e
+	auditNext++
+	if auditNext == len(auditLog) {
+		auditNext = 0
+		auditFull = true
+	}
+}
+
+// AuditLog returns recorded operations, oldest first
+func AuditLog() []AuditEntry {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	if !auditFull {
+		return append([]AuditEntry(nil), auditLog[:auditNext]...)
+	}
+	xs := make([]AuditEntry, 0, len(auditLog))
+	xs = append(xs, auditLog[auditNext:]...)
+	return append(xs, auditLog[:auditNext]...)
+}
@@ -0,0 +1,159 @@
+package cachestore
+
+import (
+	"context"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type batchLoader struct {
+	pattern  string
+	window   time.Duration
+	maxBatch int
+	fn       func(ctx context.Context, keys []string) (map[string]any, error)
+
+	mu      sync.Mutex
+	pending *batch
+}
+
+type batch struct {
+	ctx  context.Context
+	keys []string
+	once sync.Once
+	done chan struct{}
+
+	vals  map[string]any
+	err   error
+	panic any
+}
+
+var (
+	batchLoadersMu sync.Mutex
+	batchLoaders   atomic.Pointer[[]*batchLoader]
+)
+
+// RegisterBatchLoader loads GetOrSet misses of keys matching pattern in path.Match syntax
+// with fn, misses within window of the first one are loaded in one call of up to maxBatch keys,
+// zero maxBatch is unlimited.
+//
+// Keys missing from the result of fn, or with a value of another type, fall back to the loader
+// given to GetOrSet, an error from fn fails every key in the batch.
+// fn runs with the values of the first caller's context and is not canceled.
+// A nil fn or non-positive window removes the batch loader of pattern.
+func RegisterBatchLoader[T any](pattern string, window time.Duration, maxBatch int, fn func(ctx context.Context, keys []string) (map[string]T, error)) {
+	batchLoadersMu.Lock()
+	defer batchLoadersMu.Unlock()
+
+	var bs []*batchLoader
+	if p := batchLoaders.Load(); p != nil {
+		for _, b := range *p {
+			if b.pattern != pattern {
+				bs = append(bs, b)
+			}
+		}
+	}
+	if fn != nil && window > 0 {
+		bs = append(bs, &batchLoader{
+			pattern:  pattern,
+			window:   window,
+			maxBatch: maxBatch,
+			fn: func(ctx context.Context, keys []string) (map[string]any, error) {
+				vs, err := fn(ctx, keys)
+				if err != nil {
+					return nil, err
+				}
+				m := make(map[string]any, len(vs))
+				for k, v := range vs {
+					m[k] = v
+				}
+				return m, nil
+			},
+		})
+	}
+	batchLoaders.Store(&bs)
+}
+
+func batchLoaderFor(key string) *batchLoader {
+	p := batchLoaders.Load()
+	if p == nil {
+		return nil
+	}
+	for _, b := range *p {
+		if ok, _ := path.Match(b.pattern, key); ok {
+			return b
+		}
+	}
+	return nil
+}
+
+// loadBatched loads key with the batch loader of key if any, falling back to loader
+func loadBatched[T any](ctx context.Context, key string, loader func(context.Context) (T, error)) (T, error) {
+	b := batchLoaderFor(key)
+	if b == nil {
+		return loader(ctx)
+	}
+	v, ok, err := b.load(ctx, key)
+	if err != nil {
+		return *new(T), err
+	}
+	if t, match := v.(T); ok && match {
+		return t, nil
+	}
+	return loader(ctx)
+}
+
+// load adds key to the pending batch and waits for it
+func (b *batchLoader) load(ctx context.Context, key string) (any, bool, error) {
+	b.mu.Lock()
+	p := b.pending
+	if p == nil {
+		p = &batch{ctx: context.WithoutCancel(ctx), done: make(chan struct{})}
+		b.pending = p
+		afterFunc(b.window, func() { b.run(p) })
+	}
+	p.keys = append(p.keys, key)
+	full := b.maxBatch > 0 && len(p.keys) >= b.maxBatch
+	if full {
+		b.pending = nil
+	}
+	b.mu.Unlock()
+
+	if full {
+		b.run(p)
+	}
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+	if p.panic != nil {
+		panic(p.panic)
+	}
+	if p.err != nil {
+		return nil, false, p.err
+	}
+	v, ok := p.vals[key]
+	return v, ok, nil
+}
+
+// run calls fn once for the keys of p
+func (b *batchLoader) run(p *batch) {
+	p.once.Do(func() {
+		b.mu.Lock()
+		if b.pending == p {
+			b.pending = nil
+		}
+		keys := p.keys
+		b.mu.Unlock()
+
+		defer close(p.done)
+		defer func() {
+			if r := recover(); r != nil {
+				p.panic = r
+			}
+		}()
+		p.vals, p.err = b.fn(p.ctx, keys)
+	})
+}
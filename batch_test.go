@@ -0,0 +1,111 @@
+package cachestore
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBatchLoaderCoalescesMisses(t *testing.T) {
+	prefix := t.Name() + "/"
+	keys := []string{prefix + "a", prefix + "b", prefix + "c"}
+	t.Cleanup(func() {
+		RegisterBatchLoader[string](prefix+"*", 0, 0, nil)
+		for _, k := range keys {
+			Delete(k)
+		}
+	})
+
+	var (
+		mu    sync.Mutex
+		calls [][]string
+	)
+	RegisterBatchLoader(prefix+"*", 50*time.Millisecond, 0, func(_ context.Context, keys []string) (map[string]string, error) {
+		mu.Lock()
+		calls = append(calls, append([]string(nil), keys...))
+		mu.Unlock()
+
+		m := map[string]string{}
+		for _, k := range keys {
+			if !strings.HasSuffix(k, "c") { // c falls back to its own loader
+				m[k] = "batch " + k
+			}
+		}
+		return m, nil
+	})
+
+	var single atomic.Int32
+	var wg sync.WaitGroup
+	got := make([]string, len(keys))
+	for i, k := range keys {
+		wg.Add(1)
+		go func(i int, k string) {
+			defer wg.Done()
+			v, err := GetOrSet(context.Background(), k, func(context.Context) (string, error) {
+				single.Add(1)
+				return "single " + k, nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+			got[i] = v
+		}(i, k)
+	}
+	wg.Wait()
+
+	if len(calls) != 1 {
+		t.Fatalf("got %d batch calls, want 1: %v", len(calls), calls)
+	}
+	sort.Strings(calls[0])
+	if strings.Join(calls[0], ",") != strings.Join(keys, ",") {
+		t.Fatalf("got batch %v, want %v", calls[0], keys)
+	}
+	want := []string{"batch " + keys[0], "batch " + keys[1], "single " + keys[2]}
+	for i := range keys {
+		if got[i] != want[i] {
+			t.Errorf("key %s: got %q, want %q", keys[i], got[i], want[i])
+		}
+	}
+	if n := single.Load(); n != 1 {
+		t.Fatalf("got %d single loads, want 1", n)
+	}
+	if v, ok := Get[string](keys[0]); !ok || v != want[0] {
+		t.Fatalf("got %q, %v, want the batch value stored", v, ok)
+	}
+}
+
+func TestBatchLoaderMaxBatchAndError(t *testing.T) {
+	prefix := t.Name() + "/"
+	keys := []string{prefix + "a", prefix + "b"}
+	t.Cleanup(func() {
+		RegisterBatchLoader[string](prefix+"*", 0, 0, nil)
+		for _, k := range keys {
+			Delete(k)
+		}
+	})
+
+	errBackend := errors.New("backend down")
+	var calls atomic.Int32
+	RegisterBatchLoader(prefix+"*", time.Hour, 1, func(context.Context, []string) (map[string]string, error) {
+		calls.Add(1)
+		return nil, errBackend
+	})
+
+	// a full batch does not wait for the window
+	for _, k := range keys {
+		_, err := GetOrSet(context.Background(), k, func(context.Context) (string, error) {
+			return "single", nil
+		})
+		if !errors.Is(err, errBackend) {
+			t.Fatalf("key %s: got error %v, want %v", k, err, errBackend)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("got %d batch calls, want 2", n)
+	}
+}
@@ -0,0 +1,134 @@
+package cachestore
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"sync/atomic"
+)
+
+// Bloom is a bloom filter of keys known to exist,
+// a key not added is reported missing, a key added may be reported present falsely.
+type Bloom struct {
+	bits      []uint64
+	k         uint64
+	populated atomic.Bool
+}
+
+// NewBloom returns a filter sized for n keys with false positive rate p
+func NewBloom(n int, p float64) *Bloom {
+	if n <= 0 {
+		n = 1
+	}
+	if p <= 0 || p >= 1 {
+		p = 0.01
+	}
+	m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / float64(n) * math.Ln2)
+	if k < 1 {
+		k = 1
+	}
+	return &Bloom{
+		bits: make([]uint64, (uint64(m)+63)/64),
+		k:    uint64(k),
+	}
+}
+
+func (b *Bloom) hashes(key string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	s := h.Sum64()
+	return s & math.MaxUint32, s>>32 | 1
+}
+
+// Add adds key to the filter
+func (b *Bloom) Add(key string) {
+	h1, h2 := b.hashes(key)
+	m := uint64(len(b.bits)) * 64
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % m
+		p := &b.bits[bit/64]
+		mask := uint64(1) << (bit % 64)
+		for {
+			old := atomic.LoadUint64(p)
+			if old&mask != 0 || atomic.CompareAndSwapUint64(p, old, old|mask) {
+				break
+			}
+		}
+	}
+}
+
+// MayContain reports false when key was never added
+func (b *Bloom) MayContain(key string) bool {
+	h1, h2 := b.hashes(key)
+	m := uint64(len(b.bits)) * 64
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % m
+		if atomic.LoadUint64(&b.bits[bit/64])&(uint64(1)<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MarkPopulated records that every existing key was added, see Filtered
+func (b *Bloom) MarkPopulated() {
+	b.populated.Store(true)
+}
+
+// Populated reports whether MarkPopulated was called since the filter was created or reset
+func (b *Bloom) Populated() bool {
+	return b.populated.Load()
+}
+
+// Reset removes every key from the filter and marks it not populated
+func (b *Bloom) Reset() {
+	b.populated.Store(false)
+	for i := range b.bits {
+		atomic.StoreUint64(&b.bits[i], 0)
+	}
+}
+
+type filteredTier struct {
+	g Getter
+	b *Bloom
+}
+
+// Filtered returns a tier that reports a miss without reading g for keys not in b,
+// keys found in g and keys backfilled into the tier are added to b.
+//
+// Until b is marked populated every key may be present and g is always read,
+// add the keys already in g then call MarkPopulated to enable the filter.
+func Filtered(g Getter, b *Bloom) Getter {
+	return filteredTier{g: g, b: b}
+}
+
+func (t filteredTier) Get(ctx context.Context, key string) (any, bool, error) {
+	if t.b.Populated() && !t.b.MayContain(key) {
+		return nil, false, nil
+	}
+	v, ok, err := t.g.Get(ctx, key)
+	if ok && err == nil {
+		t.b.Add(key)
+	}
+	return v, ok, err
+}
+
+func (t filteredTier) Set(ctx context.Context, key string, value any) error {
+	s, ok := t.g.(Setter)
+	if !ok {
+		return nil
+	}
+	if err := s.Set(ctx, key, value); err != nil {
+		return err
+	}
+	t.b.Add(key)
+	return nil
+}
+
+func (t filteredTier) Delete(ctx context.Context, key string) error {
+	if d, ok := t.g.(Deleter); ok {
+		return d.Delete(ctx, key)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package cachestore
+
+import (
+	"context"
+	"testing"
+)
+
+type mapTier map[string]any
+
+func (t mapTier) Get(_ context.Context, key string) (any, bool, error) {
+	v, ok := t[key]
+	return v, ok, nil
+}
+
+func TestFilteredUnpopulatedReadsTier(t *testing.T) {
+	ctx := context.Background()
+	b := NewBloom(100, 0.01)
+	tier := Filtered(mapTier{"a": 1, "b": 2}, b)
+
+	if _, ok, _ := tier.Get(ctx, "a"); !ok {
+		t.Fatal("unpopulated filter hid a key of the tier")
+	}
+	if !b.MayContain("a") {
+		t.Fatal("key found in the tier not added to the filter")
+	}
+
+	b.MarkPopulated()
+	if _, ok, _ := tier.Get(ctx, "a"); !ok {
+		t.Fatal("populated filter hid an added key")
+	}
+	if b.MayContain("b") {
+		t.Skip("false positive")
+	}
+	if _, ok, _ := tier.Get(ctx, "b"); ok {
+		t.Fatal("populated filter read a key never added")
+	}
+
+	b.Reset()
+	if b.Populated() {
+		t.Fatal("filter populated after Reset")
+	}
+}
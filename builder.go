@@ -0,0 +1,43 @@
+package cachestore
+
+// Builder collects entries to replace a set of tags in one operation
+type Builder struct {
+	entries []builderEntry
+}
+
+type builderEntry struct {
+	key   string
+	value any
+	opt   *SetOptions
+}
+
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+func (b *Builder) Set(key string, value any, opts ...Option) {
+	b.entries = append(b.entries, builderEntry{key: key, value: value, opt: resolveOptions(opts)})
+}
+
+// Replace stores all entries in b, then deletes entries with any of tags set before Replace is called,
+// keys in b are replaced in place so readers never see them missing.
+//
+// Replace returns the number of deleted entries.
+func (b *Builder) Replace(tags ...string) int {
+	v := currentVersion()
+	for _, e := range b.entries {
+		set(e.key, e.value, e.opt, 0)
+	}
+
+	for _, t := range tags {
+		checkTag(t)
+	}
+	return deleteFuncBefore(v, func(key string, it *item) bool {
+		for _, t := range tags {
+			if it.tag == t {
+				return true
+			}
+		}
+		return false
+	})
+}
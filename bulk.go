@@ -0,0 +1,62 @@
+package cachestore
+
+import "sync"
+
+// bulkMu is held for writing while a Bulk commits and for reading by ConsistentRead and SnapshotView
+var bulkMu sync.RWMutex
+
+type bulkOp struct {
+	key    string
+	value  any
+	opts   []Option
+	delete bool
+}
+
+// Bulk stages sets and deletes applied together by Commit.
+//
+// Commit is atomic only for the opt-in consistent read mode, reads inside ConsistentRead and SnapshotView.
+// Get and the other reads outside it do not wait for a commit, each of them sees an entry before or after it,
+// so two plain reads may see one key before and another after the same commit.
+type Bulk struct {
+	ops []bulkOp
+}
+
+// BeginBulk returns an empty Bulk
+func BeginBulk() *Bulk {
+	return &Bulk{}
+}
+
+// Set stages setting key to value with opts
+func (b *Bulk) Set(key string, value any, opts ...Option) {
+	b.ops = append(b.ops, bulkOp{key: key, value: value, opts: opts})
+}
+
+// Delete stages deleting key
+func (b *Bulk) Delete(key string) {
+	b.ops = append(b.ops, bulkOp{key: key, delete: true})
+}
+
+// Commit applies staged operations in order and empties b,
+// reads inside ConsistentRead and SnapshotView see either none or all of them, see Bulk.
+func (b *Bulk) Commit() {
+	bulkMu.Lock()
+	defer bulkMu.Unlock()
+
+	for _, op := range b.ops {
+		if op.delete {
+			Delete(op.key)
+		} else {
+			Set(op.key, op.value, op.opts...)
+		}
+	}
+	b.ops = nil
+}
+
+// ConsistentRead calls fn in the consistent read mode, no Bulk commits while fn runs,
+// so reads inside fn see every commit as a whole. fn must not commit a Bulk.
+func ConsistentRead(fn func()) {
+	bulkMu.RLock()
+	defer bulkMu.RUnlock()
+
+	fn()
+}
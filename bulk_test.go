@@ -0,0 +1,37 @@
+package cachestore
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestBulkCommitAtomicForConsistentRead(t *testing.T) {
+	defer DeleteKeys("bulk-list", "bulk-item")
+	set := func(n int) {
+		b := BeginBulk()
+		b.Set("bulk-list", n)
+		b.Set("bulk-item", n)
+		b.Commit()
+	}
+	set(0)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 1000; i++ {
+			set(i)
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		var list, item int
+		ConsistentRead(func() {
+			list, _ = Get[int]("bulk-list")
+			item, _ = Get[int]("bulk-item")
+		})
+		if list != item {
+			t.Fatalf("read list %d with item %d", list, item)
+		}
+	}
+	wg.Wait()
+}
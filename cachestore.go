@@ -1,8 +1,9 @@
 package cachestore
 
 import (
-	"context"
-	"sync"
+	"reflect"
+	"regexp"
+	"strings"
 	"sync/atomic"
 	"time"
 )
@@ -21,13 +22,47 @@ func isDisabled() bool {
 	return atomic.LoadUint32(&disabled) == 1
 }
 
-var store sync.Map
+var deleteOnDisabledSet uint32
+
+// SetDeleteOnDisabledSet makes Set delete the existing entry while the cache is disabled,
+// so entries written while disabled are not served stale after it is enabled again.
+func SetDeleteOnDisabledSet(value bool) {
+	if value {
+		atomic.StoreUint32(&deleteOnDisabledSet, 1)
+	} else {
+		atomic.StoreUint32(&deleteOnDisabledSet, 0)
+	}
+}
+
+var version uint64
+
+func nextVersion() uint64 {
+	return atomic.AddUint64(&version, 1)
+}
+
+func currentVersion() uint64 {
+	return atomic.LoadUint64(&version)
+}
 
 type item struct {
 	tag       string
 	data      any
 	createdAt time.Time
 	expiresAt time.Time
+	version   uint64
+
+	loadDuration time.Duration
+	err          error     // cached error, see SetErr
+	keepUntil    time.Time // GC keeps the expired entry until this time, see DeleteTagStale
+	schema       int
+	checksum     uint64 // checksum of data, see SetDebugImmutable
+	meta         map[string]string
+	callers      []uintptr // see SetTrackCallers
+	keyParts     []string  // parts of the Key indexing the entry, see SetKey
+	stale        *item     // value replaced by a cached error, served stale, see WithMaxServeStale
+
+	hits       atomic.Uint64
+	lastAccess atomic.Int64 // unix nano
 }
 
 func (it *item) Expired() bool {
@@ -37,112 +72,480 @@ func (it *item) Expired() bool {
 	return time.Now().After(it.expiresAt)
 }
 
-func (it *item) CreateAfter(t time.Time) bool {
-	return it.createdAt.After(t)
+func (it *item) NewerThan(v uint64) bool {
+	return it.version > v
+}
+
+func loadItem(key string) (*item, bool) {
+	_, it, ok := loadItemMap(normalizeKey(key))
+	return it, ok
+}
+
+// loadItemMap loads normalized key and returns the map holding it,
+// entries written with an outdated schema are missing.
+func loadItemMap(key string) (engine, *item, bool) {
+	g := gens.Load()
+	m := g.current
+	it, ok := m.Load(key)
+	if !ok && g.previous != nil {
+		m = g.previous
+		it, ok = m.Load(key)
+	}
+	if !ok {
+		return nil, nil, false
+	}
+	if it.Outdated() {
+		return nil, nil, false
+	}
+	return m, it, true
+}
+
+func storeItem(key string, it *item) {
+	key = normalizeKey(key)
+	gens.Load().current.Store(key, it)
+}
+
+// storeItemUnless stores it unless keep reports the existing entry must be kept
+func storeItemUnless(key string, it *item, keep func(old *item) bool) bool {
+	key = normalizeKey(key)
+	g := gens.Load()
+	m := g.current
+	// an entry not rotated into the current generation yet is still in the previous one, see loadItemMap
+	if g.previous != nil {
+		if _, ok := m.Load(key); !ok {
+			if old, ok := g.previous.Load(key); ok && keep(old) {
+				return false
+			}
+		}
+	}
+	for {
+		old, loaded := m.LoadOrStore(key, it)
+		if !loaded {
+			return true
+		}
+		if keep(old) {
+			return false
+		}
+		if m.CompareAndSwap(key, old, it) {
+			return true
+		}
+	}
+}
+
+func deleteItem(key string) bool {
+	key = normalizeKey(key)
+	g := gens.Load()
+	it, deleted := g.current.LoadAndDelete(key)
+	if deleted {
+		removed(key, it, EventDelete)
+	}
+	if g.previous != nil {
+		if it, ok := g.previous.LoadAndDelete(key); ok {
+			removed(key, it, EventDelete)
+			deleted = true
+		}
+	}
+	return deleted
 }
 
-type SetOptions struct {
-	Tag string
-	TTL time.Duration
+// removed is called after it was removed from the store,
+// reason is EventDelete, EventExpire or EventEvict
+func removed(key string, it *item, reason EventType) {
+	expired := reason == EventExpire
+	if expired {
+		expiredAges.record(it)
+	} else {
+		deletedAges.record(it)
+	}
+	recordRemove(key, expired)
+	publish(reason, key, it)
+	if it.keyParts != nil {
+		unindexKey(key, it.keyParts)
+	}
 }
 
-func Set(key string, value any, opt *SetOptions) {
+// rangeItems calls fn for every entry in all generations,
+// m is the map holding the entry
+func rangeItems(fn func(m engine, key string, it *item) bool) {
+	g := gens.Load()
+	next := true
+	g.current.Range(func(key string, it *item) bool {
+		next = fn(g.current, key, it)
+		return next
+	})
+	if !next || g.previous == nil {
+		return
+	}
+	g.previous.Range(func(key string, it *item) bool {
+		return fn(g.previous, key, it)
+	})
+}
+
+func Set(key string, value any, opts ...Option) {
+	opt := resolveOptions(opts)
+	if hasInterceptors() {
+		intercept(&Call{Op: OpSet, Key: key, Value: value, Options: opt}, func(c *Call) (any, bool) {
+			set(c.Key, c.Value, c.Options, 0)
+			return nil, true
+		})
+		return
+	}
+	set(key, value, opt, 0)
+}
+
+// set stores value for key, loadDuration is the time taken to compute value
+func set(key string, value any, opt *SetOptions, loadDuration time.Duration) {
+	setVersion(key, value, opt, loadDuration, nextVersion())
+}
+
+// setVersion is set with the version taken when the write started, see SetOrderedWrites
+func setVersion(key string, value any, opt *SetOptions, loadDuration time.Duration, version uint64) {
+	// events, audit and recordings report the key as stored, like removed does
+	key = normalizeKey(key)
+	if opt != nil {
+		checkTag(opt.Tag)
+	}
 	if isDisabled() {
+		if atomic.LoadUint32(&deleteOnDisabledSet) == 1 {
+			deleteItem(key)
+		}
+		return
+	}
+	if !admit(key, value, opt) {
+		return
+	}
+
+	data, err := storeTransform(value)
+	if err != nil {
+		deleteItem(key)
 		return
 	}
 
 	it := item{
-		data:      value,
-		createdAt: time.Now(),
+		data:         data,
+		createdAt:    time.Now(),
+		version:      version,
+		loadDuration: loadDuration,
+		schema:       currentSchema(),
+	}
+	var coalesce time.Duration
+	if opt != nil {
+		coalesce = opt.Coalesce
+	}
+	validateOnFirstSet()
+	if isDebugImmutable() || coalesce > 0 {
+		it.checksum = checksum(data)
+	}
+	if opt != nil && opt.callers != nil {
+		it.callers = opt.callers
+	} else {
+		it.callers = captureCallers()
 	}
 	if opt != nil {
-		it.tag = opt.Tag
-		it.expiresAt = it.createdAt.Add(opt.TTL)
+		if opt.SchemaVersion != 0 {
+			it.schema = opt.SchemaVersion
+		}
+		it.tag = internTag(opt.Tag)
+		it.meta = opt.Meta
+		it.keyParts = opt.keyParts
+		ttl := opt.TTL
+		if ttl == 0 && opt.TTLPolicy != nil {
+			ttl = opt.TTLPolicy(key, value)
+		}
+		if ttl != 0 {
+			it.expiresAt = it.createdAt.Add(ttl) // negative is already expired
+		}
+		if opt.AlignTTL > 0 && ttl >= 0 {
+			if it.expiresAt.IsZero() {
+				it.expiresAt = alignUp(it.createdAt.Add(time.Nanosecond), opt.AlignTTL)
+			} else {
+				it.expiresAt = alignUp(it.expiresAt, opt.AlignTTL)
+			}
+		}
+		if opt.MaxServeStale > 0 && !it.expiresAt.IsZero() {
+			it.keepUntil = it.expiresAt.Add(opt.MaxServeStale) // GC keeps it to be served stale
+		}
+	}
+	lim := tagLimiterFor(it.tag)
+	if lim != nil && !lim.admit(key) {
+		lim.limited(key)
+		return
+	}
+	var window time.Duration
+	if opt != nil {
+		window = opt.FirstWriteWins
 	}
-	store.Store(key, &it)
+	ordered := isOrderedWrites() || (opt != nil && opt.KeepNewer)
+	if window > 0 || ordered || coalesce > 0 {
+		stored := storeItemUnless(key, &it, func(old *item) bool {
+			if old.Outdated() { // treated as missing, see SetCurrentSchema
+				return false
+			}
+			if ordered && old.NewerThan(it.version) {
+				return true
+			}
+			if old.err != nil || old.Expired() {
+				return false
+			}
+			age := it.createdAt.Sub(old.createdAt)
+			if window > 0 && age < window {
+				return true
+			}
+			// the checksum stops at checksumDepth, equal checksums are confirmed on the whole value
+			return coalesce > 0 && age < coalesce && old.checksum == it.checksum &&
+				old.tag == it.tag && old.schema == it.schema && reflect.DeepEqual(old.data, it.data)
+		})
+		if !stored {
+			return
+		}
+	} else {
+		storeItem(key, &it)
+	}
+	if lim != nil {
+		lim.add(key, &it)
+	}
+	recordSet(key, &it, value)
+	publish(EventSet, key, &it)
+	audit(OpSet, key, false, opt)
 }
 
 func Get[T any](key string) (T, bool) {
+	if hasInterceptors() {
+		v, ok := intercept(&Call{Op: OpGet, Key: key}, func(c *Call) (any, bool) {
+			return observedGet[T](c.Key)
+		})
+		t, match := v.(T)
+		return t, ok && (match || v == nil)
+	}
+	return observedGet[T](key)
+}
+
+// observedGet is get recorded in the audit log and hit rates
+func observedGet[T any](key string) (T, bool) {
+	v, ok := get[T](key)
+	audit(OpGet, key, ok, nil)
+	trackHit(key, ok)
+	return v, ok
+}
+
+func get[T any](key string) (T, bool) {
 	if isDisabled() {
 		return *new(T), false
 	}
 
-	v, ok := store.Load(key)
+	it, ok := loadItem(key)
 	if !ok {
 		return *new(T), false
 	}
-	it := v.(*item)
 	if it.Expired() {
 		return *new(T), false
 	}
-	return it.data.(T), true
+	it.checkImmutable(key)
+	v, ok := itemValue[T](it)
+	if ok {
+		it.extend(key)
+	}
+	return v, ok
+}
+
+func itemValue[T any](it *item) (T, bool) {
+	if it.err != nil {
+		return *new(T), false
+	}
+	data, err := loadTransform(it.data)
+	if err != nil {
+		return *new(T), false
+	}
+	it.recordAccess()
+	return data.(T), true
 }
 
 func GetStale[T any](key string) (T, bool) {
+	v, ok := getStale[T](key)
+	audit(OpGetStale, key, ok, nil)
+	return v, ok
+}
+
+func getStale[T any](key string) (T, bool) {
 	if isDisabled() {
 		return *new(T), false
 	}
 
-	v, ok := store.Load(key)
+	it, ok := loadItem(key)
 	if !ok {
 		return *new(T), false
 	}
-	it := v.(*item)
-	return it.data.(T), true
+	it.checkImmutable(key)
+	return itemValue[T](it)
+}
+
+// Delete deletes key and reports whether it existed
+func Delete(key string) bool {
+	if hasInterceptors() {
+		_, ok := intercept(&Call{Op: OpDelete, Key: key}, func(c *Call) (any, bool) {
+			return nil, deleteAudited(c.Key)
+		})
+		return ok
+	}
+	return deleteAudited(key)
 }
 
-func Delete(key string) {
-	store.Delete(key)
+func deleteAudited(key string) bool {
+	ok := deleteItem(key)
+	audit(OpDelete, key, ok, nil)
+	return ok
 }
 
-func DeleteTag(tag string) {
-	t := time.Now()
-	store.Range(func(key, value any) bool {
-		it := value.(*item)
-		if it.CreateAfter(t) { // new version
-			return true
-		}
-		if it.tag == tag {
-			store.Delete(key)
+// DeleteKeys deletes keys and returns the number of keys that existed
+func DeleteKeys(keys ...string) int {
+	n := 0
+	for _, k := range keys {
+		if deleteItem(k) {
+			n++
 		}
-		return true
+	}
+	return n
+}
+
+// DeleteTag deletes all entries with tag and returns the number of deleted entries.
+//
+// Every entry set before DeleteTag is called is gone when it returns,
+// entries set concurrently are kept.
+func DeleteTag(tag string) int {
+	checkTag(tag)
+	n := deleteFunc(func(key string, it *item) bool {
+		return it.tag == tag
 	})
+	dropInterned(tag)
+	audit(OpDeleteTag, tag, n > 0, nil)
+	return n
 }
 
-func Clear() {
-	t := time.Now()
-	store.Range(func(key, value any) bool {
-		it := value.(*item)
-		if it.CreateAfter(t) { // new version
+// TakeTag deletes all entries with tag like DeleteTag, and returns deleted entries
+// which value is a T and not expired.
+func TakeTag[T any](tag string) map[string]T {
+	checkTag(tag)
+	v := currentVersion()
+	xs := make(map[string]T)
+	seen := make(map[string]struct{})
+	n := 0
+	rangeItems(func(m engine, key string, it *item) bool {
+		_, shadowed := seen[key] // by current generation, whatever its entry
+		seen[key] = struct{}{}
+		it, ok := deleteWhile(m, key, it, func(it *item) bool {
+			return it.tag == tag && !it.NewerThan(v)
+		})
+		if !ok {
 			return true
 		}
-		store.Delete(key)
+		removed(key, it, EventDelete)
+		n++
+		if shadowed || it.Expired() || it.err != nil || it.Outdated() {
+			return true
+		}
+		if data, err := loadTransform(it.data); err == nil {
+			if x, ok := data.(T); ok {
+				xs[key] = x
+			}
+		}
 		return true
 	})
+	dropInterned(tag)
+	audit(OpDeleteTag, tag, n > 0, nil)
+	return xs
 }
 
-func GC() {
-	store.Range(func(key, value any) bool {
-		it := value.(*item)
-		if it.Expired() {
-			store.Delete(key)
+// DeletePrefix deletes all entries which key has prefix and returns the number of deleted entries
+func DeletePrefix(prefix string) int {
+	return deleteFunc(func(key string, it *item) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+}
+
+// DeleteMatch deletes all entries which key matches pattern and returns the number of deleted entries
+func DeleteMatch(pattern *regexp.Regexp) int {
+	return deleteFunc(func(key string, it *item) bool {
+		return pattern.MatchString(key)
+	})
+}
+
+// deleteFunc deletes all entries set before it is called that match fn
+func deleteFunc(fn func(key string, it *item) bool) int {
+	return deleteFuncBefore(currentVersion(), fn)
+}
+
+// deleteFuncBefore deletes all entries not newer than version v that match fn
+func deleteFuncBefore(v uint64, fn func(key string, it *item) bool) int {
+	n := 0
+	rangeItems(func(m engine, key string, it *item) bool {
+		d, ok := deleteWhile(m, key, it, func(it *item) bool {
+			return !it.NewerThan(v) && fn(key, it) // newer is a new version
+		})
+		if ok {
+			removed(key, d, EventDelete)
+			n++
 		}
 		return true
 	})
+	return n
 }
 
-func RunGCInterval(ctx context.Context, d time.Duration) {
-	if d <= 0 {
-		return
+// deleteWhile deletes the entry of key in m, starting from it, while fn reports true for the entry,
+// and returns the deleted entry.
+//
+// touch, extend and DeleteTagStale replace an entry with a copy of the same version,
+// a delete losing the race to one of them reloads the entry and retries.
+func deleteWhile(m engine, key string, it *item, fn func(it *item) bool) (*item, bool) {
+	for fn(it) {
+		if m.CompareAndDelete(key, it) {
+			return it, true
+		}
+		var ok bool
+		if it, ok = m.Load(key); !ok {
+			return nil, false
+		}
 	}
-	t := time.NewTicker(d)
-	defer t.Stop()
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-t.C:
-			GC()
+	return nil, false
+}
+
+// Clear deletes all entries set before Clear is called.
+func Clear() {
+	ClearWith()
+}
+
+type ClearOptions struct {
+	Prefix       string   // delete only keys with Prefix, empty deletes every key
+	SkipNoExpiry bool     // keep entries without expiry
+	KeepTags     []string // keep entries with any of these tags
+}
+
+// ClearWith deletes entries set before ClearWith is called that opts does not keep,
+// and returns the number of deleted entries.
+//
+// EventClear is published only when opts keeps nothing.
+func ClearWith(opts ...ClearOptions) int {
+	var o ClearOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	n := deleteFunc(func(key string, it *item) bool {
+		if !strings.HasPrefix(key, o.Prefix) {
+			return false
 		}
+		if o.SkipNoExpiry && it.expiresAt.IsZero() {
+			return false
+		}
+		for _, t := range o.KeepTags {
+			if it.tag == t {
+				return false
+			}
+		}
+		return true
+	})
+	audit(OpClear, o.Prefix, true, nil)
+	if o.Prefix == "" && !o.SkipNoExpiry && len(o.KeepTags) == 0 {
+		dropAllInterned()
+		publish(EventClear, "", nil)
 	}
+	return n
 }
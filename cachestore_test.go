@@ -0,0 +1,192 @@
+package cachestore
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// racingDeletes calls touch on keys while they are set and deleted by DeleteTag,
+// and fails for keys set before DeleteTag that survived it.
+func racingDeletes(t *testing.T, touch func(key string)) {
+	t.Helper()
+
+	tag := t.Name()
+	keys := make([]string, 100)
+	for i := range keys {
+		keys[i] = t.Name() + "/" + strconv.Itoa(i)
+	}
+	t.Cleanup(func() { DeleteKeys(keys...) })
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				for _, k := range keys {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					touch(k)
+				}
+			}
+		}()
+	}
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	for round := 0; round < 2000; round++ {
+		for i, k := range keys {
+			Set(k, i, WithTag(tag), WithTTL(time.Hour))
+		}
+		DeleteTag(tag)
+		for _, k := range keys {
+			if _, ok := loadItem(k); ok {
+				t.Fatalf("round %d: %s set before DeleteTag survived it", round, k)
+			}
+		}
+	}
+}
+
+func TestDeleteTagRacingTouch(t *testing.T) {
+	racingDeletes(t, func(key string) {
+		GetAndTouch[int](key, time.Hour)
+	})
+}
+
+func TestDeleteTagRacingExtendOnHit(t *testing.T) {
+	SetExtendOnHit(func(string, EntryMeta) time.Duration { return time.Millisecond })
+	t.Cleanup(func() { SetExtendOnHit(nil) })
+
+	racingDeletes(t, func(key string) {
+		Get[int](key)
+	})
+}
+
+// TestDeleteTagReadYourWrites checks that after DeleteTag returns,
+// the calling goroutine sees only entries set concurrently with or after it.
+func TestDeleteTagReadYourWrites(t *testing.T) {
+	tag := t.Name()
+	keys := make([]string, 50)
+	for i := range keys {
+		keys[i] = t.Name() + "/" + strconv.Itoa(i)
+	}
+	t.Cleanup(func() { DeleteKeys(keys...) })
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				Set(keys[i%len(keys)], i, WithTag(tag))
+			}
+		}()
+	}
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	for round := 0; round < 500; round++ {
+		v := currentVersion()
+		DeleteTag(tag)
+		for _, k := range keys {
+			if it, ok := loadItem(k); ok && !it.NewerThan(v) {
+				t.Fatalf("round %d: %s of version %d set before DeleteTag at %d survived it", round, k, it.version, v)
+			}
+		}
+	}
+}
+
+// TestDeleteSetOrdering checks that an entry set after Delete returns is kept,
+// and that Delete removes an entry set before it is called.
+func TestDeleteSetOrdering(t *testing.T) {
+	key := t.Name()
+	t.Cleanup(func() { Delete(key) })
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			k := key + "/" + strconv.Itoa(w)
+			defer Delete(k)
+			for i := 0; i < 2000; i++ {
+				Set(k, i)
+				if !Delete(k) {
+					t.Errorf("%s: Delete missed the entry just set", k)
+					return
+				}
+				if _, ok := Get[int](k); ok {
+					t.Errorf("%s: Get saw the entry after Delete returned", k)
+					return
+				}
+				Set(k, i)
+				if v, ok := Get[int](k); !ok || v != i {
+					t.Errorf("%s: got %d, %v after Set, want %d", k, v, ok, i)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+func TestNegativeTTLExpired(t *testing.T) {
+	key := t.Name()
+	defer Delete(key)
+
+	Set(key, 1)
+	Set(key, 2, WithTTL(-time.Second))
+	if _, ok := Get[int](key); ok {
+		t.Fatal("entry with negative TTL is visible")
+	}
+	Set(key, 3, &SetOptions{TTLPolicy: func(string, any) time.Duration { return -1 }})
+	if _, ok := Get[int](key); ok {
+		t.Fatal("entry with negative TTLPolicy result is visible")
+	}
+}
+
+func TestOrderedWritesAcrossRotate(t *testing.T) {
+	SetOrderedWrites(true)
+	defer SetOrderedWrites(false)
+	key := t.Name()
+	defer Delete(key)
+
+	late := nextVersion()
+	Set(key, "newer")
+	Rotate(time.Minute)
+	setVersion(key, "late", nil, 0, late)
+	if v, _ := Get[string](key); v != "newer" {
+		t.Fatalf("Get = %q, want the newer entry kept from the previous generation", v)
+	}
+}
+
+func TestTakeTagShadowedByExpired(t *testing.T) {
+	key := t.Name()
+	tag := t.Name() + "-tag"
+	defer Delete(key)
+
+	Set(key, "old", WithTag(tag))
+	Rotate(time.Minute)
+	Set(key, "expired", WithTag(tag), WithTTL(time.Nanosecond))
+	time.Sleep(time.Millisecond)
+
+	if xs := TakeTag[string](tag); len(xs) != 0 {
+		t.Fatalf("TakeTag = %v, want the previous generation shadowed by the expired entry", xs)
+	}
+}
@@ -0,0 +1,202 @@
+package cachestore
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type Getter interface {
+	Get(ctx context.Context, key string) (any, bool, error)
+}
+
+// Setter is implemented by tiers that can be backfilled by a Chain
+type Setter interface {
+	Set(ctx context.Context, key string, value any) error
+}
+
+// Deleter is implemented by tiers that can be deleted from by a Chain
+type Deleter interface {
+	Delete(ctx context.Context, key string) error
+}
+
+type TierStats struct {
+	Hits   uint64
+	Misses uint64
+	Errors uint64
+}
+
+type tier struct {
+	g      Getter
+	hits   uint64
+	misses uint64
+	errors uint64
+}
+
+type Chained struct {
+	tiers []*tier
+
+	deleteRetries int
+	onDeleteError func(key string, tier int, err error)
+
+	repairNewer func(a, b any) bool
+	divergences uint64
+}
+
+// Chain composes tiers into a single read path, tiers are read in order
+// and a hit backfills every tier before it that implements Setter.
+func Chain(tiers ...Getter) *Chained {
+	c := Chained{tiers: make([]*tier, len(tiers))}
+	for i, g := range tiers {
+		c.tiers[i] = &tier{g: g}
+	}
+	return &c
+}
+
+// Get returns the value from the first tier that has key,
+// a tier that fails is skipped, the first error is returned only when no tier has key.
+func (c *Chained) Get(ctx context.Context, key string) (any, bool, error) {
+	var firstErr error
+	for i, t := range c.tiers {
+		v, ok, err := t.g.Get(ctx, key)
+		if err != nil {
+			atomic.AddUint64(&t.errors, 1)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if !ok {
+			atomic.AddUint64(&t.misses, 1)
+			continue
+		}
+		atomic.AddUint64(&t.hits, 1)
+		stale := c.tiers[:i]
+		if c.repairNewer != nil {
+			v, stale = c.repair(ctx, key, i, v)
+		}
+		for _, up := range stale {
+			if s, ok := up.g.(Setter); ok {
+				s.Set(ctx, key, v)
+			}
+		}
+		return v, true, nil
+	}
+	return nil, false, firstErr
+}
+
+// repair reads tiers after tier i, which returned v, and returns the newest value
+// with the tiers that must be backfilled with it
+func (c *Chained) repair(ctx context.Context, key string, i int, v any) (any, []*tier) {
+	stale := append([]*tier(nil), c.tiers[:i]...)
+	fresh := []*tier{c.tiers[i]}
+	diverged := false
+	for _, t := range c.tiers[i+1:] {
+		x, ok, err := t.g.Get(ctx, key)
+		if err != nil || !ok {
+			continue
+		}
+		switch {
+		case c.repairNewer(x, v):
+			v = x
+			stale = append(stale, fresh...)
+			fresh = []*tier{t}
+			diverged = true
+		case c.repairNewer(v, x):
+			stale = append(stale, t)
+			diverged = true
+		default:
+			fresh = append(fresh, t)
+		}
+	}
+	if diverged {
+		atomic.AddUint64(&c.divergences, 1)
+	}
+	return v, stale
+}
+
+// ReadRepair makes Get read every tier after the first hit, and return the newest value by newer,
+// tiers with an older value are backfilled with it, it must be called before the chain is used.
+//
+// newer reports whether a is newer than b.
+func (c *Chained) ReadRepair(newer func(a, b any) bool) {
+	c.repairNewer = newer
+}
+
+// Divergences returns the number of reads where tiers had different values, see ReadRepair
+func (c *Chained) Divergences() uint64 {
+	return atomic.LoadUint64(&c.divergences)
+}
+
+// Stats returns stats for each tier, in the order tiers were given to Chain
+func (c *Chained) Stats() []TierStats {
+	xs := make([]TierStats, len(c.tiers))
+	for i, t := range c.tiers {
+		xs[i] = TierStats{
+			Hits:   atomic.LoadUint64(&t.hits),
+			Misses: atomic.LoadUint64(&t.misses),
+			Errors: atomic.LoadUint64(&t.errors),
+		}
+	}
+	return xs
+}
+
+// OnDeleteError retries a failed tier delete up to retries times and then calls fn,
+// it must be called before the chain is used.
+func (c *Chained) OnDeleteError(retries int, fn func(key string, tier int, err error)) {
+	c.deleteRetries = retries
+	c.onDeleteError = fn
+}
+
+// Delete deletes key from every tier that implements Deleter, the last tier first,
+// so a tier is never deleted from before the tiers it is backfilled from.
+//
+// A tier that still fails after retries is reported to the OnDeleteError hook
+// and the tiers before it are deleted from anyway, the first error is returned.
+func (c *Chained) Delete(ctx context.Context, key string) error {
+	var firstErr error
+	for i := len(c.tiers) - 1; i >= 0; i-- {
+		d, ok := c.tiers[i].g.(Deleter)
+		if !ok {
+			continue
+		}
+		err := d.Delete(ctx, key)
+		for retry := 0; err != nil && retry < c.deleteRetries && ctx.Err() == nil; retry++ {
+			err = d.Delete(ctx, key)
+		}
+		if err == nil {
+			continue
+		}
+		if c.onDeleteError != nil {
+			c.onDeleteError(key, i, err)
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+type localTier struct {
+	opt *SetOptions
+}
+
+// Local returns a tier backed by the package store,
+// backfilled values are stored with opts.
+func Local(opts ...Option) Getter {
+	return localTier{opt: resolveOptions(opts)}
+}
+
+func (t localTier) Get(_ context.Context, key string) (any, bool, error) {
+	v, ok := Get[any](key)
+	return v, ok, nil
+}
+
+func (t localTier) Set(_ context.Context, key string, value any) error {
+	set(key, value, t.opt, 0)
+	return nil
+}
+
+func (t localTier) Delete(_ context.Context, key string) error {
+	Delete(key)
+	return nil
+}
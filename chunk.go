@@ -0,0 +1,182 @@
+package cachestore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var chunkMagic = []byte("cachestore-chunks:")
+
+// chunkSep separates a key from its chunk names, keys containing it are reserved for chunks
+const chunkSep = "\x00"
+
+type chunkedTier struct {
+	g       Getter
+	maxSize int
+}
+
+// Chunked returns a tier storing []byte values larger than maxSize in g as chunks of at most maxSize,
+// with a manifest under key naming the version of the write, its chunks are stored under
+// key, NUL, version, NUL and the chunk number. Chunks of the replaced or deleted value are deleted
+// when g implements Deleter. A value with a missing or corrupted chunk is a miss.
+//
+// g must implement Setter, other values are passed through unchanged.
+// Set fails for keys containing NUL, as they could collide with chunks.
+func Chunked(g Getter, maxSize int) Getter {
+	return chunkedTier{g: g, maxSize: maxSize}
+}
+
+func chunkKey(key, version string, i int) string {
+	return key + chunkSep + version + chunkSep + strconv.Itoa(i)
+}
+
+// chunkManifest is the record stored under the key of a chunked value
+type chunkManifest struct {
+	version string // written chunks are named by version, so writers never mix chunks
+	n, size int
+	sum     uint32 // crc32 of the value
+}
+
+func (m chunkManifest) encode() []byte {
+	return fmt.Appendf(append([]byte(nil), chunkMagic...), "%s:%d:%d:%d", m.version, m.n, m.size, m.sum)
+}
+
+func parseChunkManifest(b []byte) (chunkManifest, bool) {
+	if !bytes.HasPrefix(b, chunkMagic) {
+		return chunkManifest{}, false
+	}
+	xs := strings.Split(string(b[len(chunkMagic):]), ":")
+	if len(xs) != 4 || xs[0] == "" {
+		return chunkManifest{}, false
+	}
+	n, err1 := strconv.Atoi(xs[1])
+	size, err2 := strconv.Atoi(xs[2])
+	sum, err3 := strconv.ParseUint(xs[3], 10, 32)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return chunkManifest{}, false
+	}
+	return chunkManifest{version: xs[0], n: n, size: size, sum: uint32(sum)}, true
+}
+
+// chunkVersion returns a version unique to this write, across processes sharing g
+func chunkVersion() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36) + "." + strconv.FormatUint(nextVersion(), 36)
+}
+
+// manifest returns the manifest stored under key, if any
+func (t chunkedTier) manifest(ctx context.Context, key string) (chunkManifest, bool) {
+	v, ok, err := t.g.Get(ctx, key)
+	if err != nil || !ok {
+		return chunkManifest{}, false
+	}
+	b, _ := v.([]byte)
+	return parseChunkManifest(b)
+}
+
+// deleteChunks deletes the first n chunks of version, it stops at the first error
+func (t chunkedTier) deleteChunks(ctx context.Context, key, version string, n int) error {
+	d, ok := t.g.(Deleter)
+	if !ok {
+		return nil
+	}
+	for i := 0; i < n; i++ {
+		if err := d.Delete(ctx, chunkKey(key, version, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t chunkedTier) Get(ctx context.Context, key string) (any, bool, error) {
+	v, ok, err := t.g.Get(ctx, key)
+	if err != nil || !ok {
+		return v, ok, err
+	}
+	b, isBytes := v.([]byte)
+	if !isBytes {
+		return v, true, nil
+	}
+	m, ok := parseChunkManifest(b)
+	if !ok {
+		return v, true, nil
+	}
+
+	buf := make([]byte, 0, m.size)
+	for i := 0; i < m.n; i++ {
+		c, ok, err := t.g.Get(ctx, chunkKey(key, m.version, i))
+		if err != nil {
+			return nil, false, err
+		}
+		b, isBytes := c.([]byte)
+		if !ok || !isBytes {
+			return nil, false, nil
+		}
+		buf = append(buf, b...)
+	}
+	if len(buf) != m.size || crc32.ChecksumIEEE(buf) != m.sum {
+		return nil, false, nil
+	}
+	return buf, true, nil
+}
+
+func (t chunkedTier) Set(ctx context.Context, key string, value any) error {
+	s, ok := t.g.(Setter)
+	if !ok {
+		return nil
+	}
+	if strings.Contains(key, chunkSep) {
+		return fmt.Errorf("cachestore: chunked tier key %q contains NUL, reserved for chunks", key)
+	}
+	old, hasOld := t.manifest(ctx, key)
+
+	b, isBytes := value.([]byte)
+	if !isBytes || t.maxSize <= 0 || len(b) <= t.maxSize {
+		if err := s.Set(ctx, key, value); err != nil {
+			return err
+		}
+	} else {
+		m := chunkManifest{version: chunkVersion(), size: len(b), sum: crc32.ChecksumIEEE(b)}
+		for off := 0; off < len(b); off += t.maxSize {
+			end := off + t.maxSize
+			if end > len(b) {
+				end = len(b)
+			}
+			if err := s.Set(ctx, chunkKey(key, m.version, m.n), b[off:end]); err != nil {
+				t.deleteChunks(ctx, key, m.version, m.n)
+				return err
+			}
+			m.n++
+		}
+		// the manifest is written last, readers never see a manifest without its chunks
+		if err := s.Set(ctx, key, m.encode()); err != nil {
+			t.deleteChunks(ctx, key, m.version, m.n)
+			return err
+		}
+	}
+
+	if hasOld {
+		return t.deleteChunks(ctx, key, old.version, old.n)
+	}
+	return nil
+}
+
+func (t chunkedTier) Delete(ctx context.Context, key string) error {
+	d, ok := t.g.(Deleter)
+	if !ok {
+		return nil
+	}
+	m, hasChunks := t.manifest(ctx, key)
+	// the manifest is deleted first, readers never see a manifest without its chunks
+	if err := d.Delete(ctx, key); err != nil {
+		return err
+	}
+	if hasChunks {
+		return t.deleteChunks(ctx, key, m.version, m.n)
+	}
+	return nil
+}
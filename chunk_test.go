@@ -0,0 +1,61 @@
+package cachestore
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func (t mapTier) Set(_ context.Context, key string, value any) error {
+	t[key] = value
+	return nil
+}
+
+func (t mapTier) Delete(_ context.Context, key string) error {
+	delete(t, key)
+	return nil
+}
+
+func TestChunkedDeletesStaleChunks(t *testing.T) {
+	ctx := context.Background()
+	g := mapTier{}
+	tier := Chunked(g, 4).(Setter)
+
+	big := []byte("0123456789")
+	if err := tier.Set(ctx, "k", big); err != nil {
+		t.Fatal(err)
+	}
+	if len(g) != 4 {
+		t.Fatalf("tier holds %d records, want a manifest and 3 chunks", len(g))
+	}
+	if v, ok, _ := tier.(Getter).Get(ctx, "k"); !ok || !bytes.Equal(v.([]byte), big) {
+		t.Fatalf("Get = %q, %v", v, ok)
+	}
+
+	// replacing the value deletes the chunks of the previous write
+	if err := tier.Set(ctx, "k", []byte("abcdef")); err != nil {
+		t.Fatal(err)
+	}
+	if len(g) != 3 {
+		t.Fatalf("tier holds %d records after rewrite, want a manifest and 2 chunks", len(g))
+	}
+	if err := tier.Set(ctx, "k", []byte("ab")); err != nil {
+		t.Fatal(err)
+	}
+	if len(g) != 1 {
+		t.Fatalf("tier holds %d records after a small rewrite, want 1", len(g))
+	}
+
+	tier.Set(ctx, "k", big)
+	tier.(Deleter).Delete(ctx, "k")
+	if len(g) != 0 {
+		t.Fatalf("tier holds %d records after Delete", len(g))
+	}
+}
+
+func TestChunkedReservedKey(t *testing.T) {
+	tier := Chunked(mapTier{}, 4).(Setter)
+	if err := tier.Set(context.Background(), "a\x00b", []byte("x")); err == nil {
+		t.Fatal("Set of a key with the chunk separator succeeded")
+	}
+}
@@ -0,0 +1,74 @@
+package cachestore
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrColdStart is returned by GetOrSet when the cold start loader limit is reached, see SetColdStart
+var ErrColdStart = errors.New("cachestore: too many loaders during cold start")
+
+var (
+	coldSlots  atomic.Pointer[chan struct{}]
+	coldReject uint32
+)
+
+// SetColdStart limits GetOrSet to maxLoaders concurrent loaders for distinct keys until the cache is warm,
+// extra loaders wait for a free slot, or fail with ErrColdStart when reject is true.
+//
+// The cache is warm when EndColdStart is called or every key set by SetWarmKeys is cached.
+func SetColdStart(maxLoaders int, reject bool) {
+	if reject {
+		atomic.StoreUint32(&coldReject, 1)
+	} else {
+		atomic.StoreUint32(&coldReject, 0)
+	}
+	if maxLoaders <= 0 {
+		coldSlots.Store(nil)
+		return
+	}
+	slots := make(chan struct{}, maxLoaders)
+	coldSlots.Store(&slots)
+}
+
+// EndColdStart removes the cold start loader limit
+func EndColdStart() {
+	coldSlots.Store(nil)
+}
+
+func warmKeysReady() bool {
+	warmKeysMu.RLock()
+	defer warmKeysMu.RUnlock()
+
+	return len(warmKeys) > 0 && cached(warmKeys)
+}
+
+// coldStartSlot takes a loader slot while the cache is cold and returns a function to release it
+func coldStartSlot(ctx context.Context) (func(), error) {
+	p := coldSlots.Load()
+	if p == nil {
+		return func() {}, nil
+	}
+	if warmKeysReady() {
+		coldSlots.CompareAndSwap(p, nil)
+		return func() {}, nil
+	}
+
+	slots := *p
+	release := func() { <-slots }
+	if atomic.LoadUint32(&coldReject) == 1 {
+		select {
+		case slots <- struct{}{}:
+			return release, nil
+		default:
+			return nil, ErrColdStart
+		}
+	}
+	select {
+	case slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
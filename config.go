@@ -0,0 +1,75 @@
+package cachestore
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	Disabled        bool
+	GCInterval      time.Duration // zero stops the GC loop started by ApplyConfig
+	TrackAccess     bool
+	StrictTags      bool
+	EarlyExpiration float64 // beta, see SetEarlyExpiration
+
+	DeleteOnDisabledSet bool
+}
+
+var (
+	configMu      sync.Mutex
+	config        Config
+	configApplied bool
+	stopGC        context.CancelFunc
+)
+
+// CurrentConfig returns the config last given to ApplyConfig
+func CurrentConfig() Config {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	return config
+}
+
+// ApplyConfig applies settings in c that changed since the last call,
+// the first call applies every setting.
+func ApplyConfig(c Config) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	old := config
+	all := !configApplied
+
+	if all || c.Disabled != old.Disabled {
+		SetDisable(c.Disabled)
+	}
+	if all || c.DeleteOnDisabledSet != old.DeleteOnDisabledSet {
+		SetDeleteOnDisabledSet(c.DeleteOnDisabledSet)
+	}
+	if all || c.TrackAccess != old.TrackAccess {
+		SetTrackAccess(c.TrackAccess)
+	}
+	if all || c.StrictTags != old.StrictTags {
+		SetStrictTags(c.StrictTags)
+	}
+	if all || c.EarlyExpiration != old.EarlyExpiration {
+		SetEarlyExpiration(c.EarlyExpiration)
+	}
+	if all || c.GCInterval != old.GCInterval {
+		if stopGC != nil {
+			stopGC()
+			stopGC = nil
+		}
+		if c.GCInterval > 0 {
+			var ctx context.Context
+			ctx, stopGC = context.WithCancel(context.Background())
+			interval := c.GCInterval
+			go track(func() {
+				RunGCInterval(ctx, interval)
+			})
+		}
+	}
+
+	config = c
+	configApplied = true
+}
@@ -0,0 +1,31 @@
+package cachestore
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrDeadlineTooShort is returned instead of calling a loader
+// when the context deadline leaves less than the time set by SetMinLoadTime
+var ErrDeadlineTooShort = errors.New("cachestore: deadline too short to load")
+
+var minLoadTime atomic.Int64
+
+// SetMinLoadTime makes GetOrSet return ErrDeadlineTooShort without calling the loader
+// when the context deadline is less than d away, zero disables the check
+func SetMinLoadTime(d time.Duration) {
+	minLoadTime.Store(int64(d))
+}
+
+func checkDeadline(ctx context.Context) error {
+	d := time.Duration(minLoadTime.Load())
+	if d <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return ErrDeadlineTooShort
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package cachestore
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	debounceMu sync.Mutex
+	debounced  = map[string]*time.Timer{}
+)
+
+// DeleteTagDebounced deletes tag after window,
+// calls for the same tag during the window are coalesced into a single DeleteTag.
+func DeleteTagDebounced(tag string, window time.Duration) {
+	if window <= 0 {
+		DeleteTag(tag)
+		return
+	}
+
+	debounceMu.Lock()
+	defer debounceMu.Unlock()
+
+	if _, ok := debounced[tag]; ok {
+		return
+	}
+	debounced[tag] = afterFunc(window, func() {
+		debounceMu.Lock()
+		delete(debounced, tag)
+		debounceMu.Unlock()
+
+		DeleteTag(tag)
+	})
+}
@@ -0,0 +1,212 @@
+// Package debugpage serves an HTML page summarizing the state of cachestore
+package debugpage
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/moonrhythm/cachestore"
+)
+
+const (
+	topKeys      = 20
+	recentEvents = 50
+)
+
+type keyRow struct {
+	Key  string
+	Meta cachestore.EntryMeta
+}
+
+type tagRow struct {
+	Tag   string
+	Count int
+}
+
+type page struct {
+	Now          time.Time
+	Config       cachestore.Config
+	Engine       cachestore.Engine
+	Entries      int
+	MemoryUsage  int64
+	InFlight     int
+	Goroutines   int
+	StaleServed  uint64
+	Dropped      uint64
+	HitWindows   cachestore.HitWindowStats
+	HitRates     map[string]float64
+	Latencies    map[string]cachestore.LatencyStats
+	GC           cachestore.GCState
+	GCRuns       []cachestore.GCResult
+	Intern       cachestore.InternStats
+	TopKeys      []keyRow
+	Tags         []tagRow
+	RecentEvents []cachestore.Event
+}
+
+type handler struct {
+	mu     sync.Mutex
+	events []cachestore.Event // expire and evict events, oldest first
+}
+
+// Handler returns a handler serving the debug page and a function to stop it,
+// the handler keeps the recent expire and evict events from when it is created until stopped.
+func Handler() (http.Handler, func()) {
+	h := &handler{}
+	stop := cachestore.EventsFunc(recentEvents, func(e cachestore.Event) {
+		if e.Type != cachestore.EventExpire && e.Type != cachestore.EventEvict {
+			return
+		}
+		h.mu.Lock()
+		if len(h.events) == recentEvents {
+			copy(h.events, h.events[1:])
+			h.events = h.events[:recentEvents-1]
+		}
+		h.events = append(h.events, e)
+		h.mu.Unlock()
+	})
+	return h, stop
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := page{
+		Now:         time.Now(),
+		Config:      cachestore.CurrentConfig(),
+		Engine:      cachestore.CurrentEngine(),
+		MemoryUsage: cachestore.MemoryUsage(),
+		InFlight:    cachestore.InFlight(),
+		Goroutines:  cachestore.Goroutines(),
+		StaleServed: cachestore.StaleServed(),
+		Dropped:     cachestore.DroppedEvents(),
+		HitWindows:  cachestore.HitWindows(),
+		HitRates:    cachestore.HitRates(),
+		Latencies:   cachestore.LoadLatencies(),
+		GC:          cachestore.GCStatus(),
+		GCRuns:      cachestore.GCResults(),
+		Intern:      cachestore.InternStatus(),
+	}
+
+	tags := map[string]int{}
+	for _, k := range cachestore.Keys() {
+		m, ok := cachestore.Meta(k)
+		if !ok {
+			continue
+		}
+		p.Entries++
+		if m.Tag != "" {
+			tags[m.Tag]++
+		}
+		p.TopKeys = append(p.TopKeys, keyRow{Key: k, Meta: m})
+	}
+	sort.SliceStable(p.TopKeys, func(i, j int) bool {
+		return p.TopKeys[i].Meta.HitCount > p.TopKeys[j].Meta.HitCount
+	})
+	if len(p.TopKeys) > topKeys {
+		p.TopKeys = p.TopKeys[:topKeys]
+	}
+	for t, n := range tags {
+		p.Tags = append(p.Tags, tagRow{Tag: t, Count: n})
+	}
+	sort.Slice(p.Tags, func(i, j int) bool {
+		if p.Tags[i].Count != p.Tags[j].Count {
+			return p.Tags[i].Count > p.Tags[j].Count
+		}
+		return p.Tags[i].Tag < p.Tags[j].Tag
+	})
+
+	h.mu.Lock()
+	p.RecentEvents = append([]cachestore.Event(nil), h.events...)
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := tmpl.Execute(w, p); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+var tmpl = template.Must(template.New("").Parse(`<!doctype html>
+<html>
+<head>
+<title>cachestore</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+td, th { border: 1px solid #ccc; padding: 2px 6px; text-align: left; }
+</style>
+</head>
+<body>
+<h1>cachestore</h1>
+<p>{{.Now.Format "2006-01-02 15:04:05 MST"}}</p>
+
+<h2>Overview</h2>
+<table>
+<tr><th>Engine</th><td>{{.Engine}}</td></tr>
+<tr><th>Entries</th><td>{{.Entries}}</td></tr>
+<tr><th>Memory usage (approx. bytes)</th><td>{{.MemoryUsage}}</td></tr>
+<tr><th>Loaders in flight</th><td>{{.InFlight}}</td></tr>
+<tr><th>Goroutines</th><td>{{.Goroutines}}</td></tr>
+<tr><th>Stale served on failure</th><td>{{.StaleServed}}</td></tr>
+<tr><th>Dropped events</th><td>{{.Dropped}}</td></tr>
+<tr><th>Interned tags</th><td>{{.Intern.Strings}} ({{.Intern.BytesSaved}} bytes saved, {{.Intern.Dropped}} dropped, {{.Intern.Skipped}} skipped)</td></tr>
+</table>
+
+<h2>Config</h2>
+<table>
+<tr><th>Disabled</th><td>{{.Config.Disabled}}</td></tr>
+<tr><th>GC interval</th><td>{{.Config.GCInterval}}</td></tr>
+<tr><th>Track access</th><td>{{.Config.TrackAccess}}</td></tr>
+<tr><th>Strict tags</th><td>{{.Config.StrictTags}}</td></tr>
+<tr><th>Early expiration</th><td>{{.Config.EarlyExpiration}}</td></tr>
+<tr><th>Delete on disabled set</th><td>{{.Config.DeleteOnDisabledSet}}</td></tr>
+</table>
+
+<h2>Hit ratio</h2>
+<table>
+<tr><th>1m</th><th>5m</th><th>1h</th></tr>
+<tr><td>{{printf "%.3f" .HitWindows.Minute}}</td><td>{{printf "%.3f" .HitWindows.FiveMinutes}}</td><td>{{printf "%.3f" .HitWindows.Hour}}</td></tr>
+</table>
+{{if .HitRates}}<table>
+<tr><th>Pattern</th><th>Decayed hit rate</th></tr>
+{{range $p, $r := .HitRates}}<tr><td>{{$p}}</td><td>{{printf "%.3f" $r}}</td></tr>
+{{end}}</table>{{end}}
+
+{{if .Latencies}}<h2>Loader latency</h2>
+<table>
+<tr><th>Pattern</th><th>Count</th><th>p50</th><th>p90</th><th>p99</th><th>max</th></tr>
+{{range $p, $l := .Latencies}}<tr><td>{{$p}}</td><td>{{$l.Count}}</td><td>{{$l.P50}}</td><td>{{$l.P90}}</td><td>{{$l.P99}}</td><td>{{$l.Max}}</td></tr>
+{{end}}</table>{{end}}
+
+<h2>GC</h2>
+<table>
+<tr><th>Running</th><td>{{.GC.Running}}</td></tr>
+<tr><th>Interval</th><td>{{.GC.Interval}}</td></tr>
+<tr><th>Last run</th><td>{{if not .GC.LastRun.IsZero}}{{.GC.LastRun.Format "15:04:05"}}{{end}}</td></tr>
+</table>
+{{if .GCRuns}}<table>
+<tr><th>Scanned</th><th>Removed</th><th>Duration</th></tr>
+{{range .GCRuns}}<tr><td>{{.Scanned}}</td><td>{{.Removed}}</td><td>{{.Duration}}</td></tr>
+{{end}}</table>{{end}}
+
+<h2>Top keys</h2>
+<table>
+<tr><th>Key</th><th>Tag</th><th>Hits</th><th>Created</th><th>Expires</th></tr>
+{{range .TopKeys}}<tr><td>{{.Key}}</td><td>{{.Meta.Tag}}</td><td>{{.Meta.HitCount}}</td><td>{{.Meta.CreatedAt.Format "15:04:05"}}</td><td>{{if not .Meta.ExpiresAt.IsZero}}{{.Meta.ExpiresAt.Format "15:04:05"}}{{end}}</td></tr>
+{{end}}</table>
+
+<h2>Tags</h2>
+<table>
+<tr><th>Tag</th><th>Entries</th></tr>
+{{range .Tags}}<tr><td>{{.Tag}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+
+<h2>Recent expirations and evictions</h2>
+<table>
+<tr><th>Time</th><th>Type</th><th>Key</th><th>Tag</th></tr>
+{{range .RecentEvents}}<tr><td>{{.Time.Format "15:04:05"}}</td><td>{{.Type}}</td><td>{{.Key}}</td><td>{{.Tag}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,31 @@
+package cachestore
+
+import (
+	"hash/fnv"
+	"sync/atomic"
+)
+
+var deterministic uint32
+
+// SetDeterministic disables randomized behaviors for reproducible tests,
+// early expiration and GC jitter are off and sampling decides by a hash of the key instead of at random.
+//
+// Expiry still follows the wall clock.
+func SetDeterministic(value bool) {
+	if value {
+		atomic.StoreUint32(&deterministic, 1)
+	} else {
+		atomic.StoreUint32(&deterministic, 0)
+	}
+}
+
+func isDeterministic() bool {
+	return atomic.LoadUint32(&deterministic) == 1
+}
+
+// keyFraction returns a number in [0, 1) fixed for key
+func keyFraction(key string) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return float64(h.Sum64()>>11) / (1 << 53)
+}
@@ -0,0 +1,29 @@
+package cachestore
+
+// ViewDiff lists keys that differ between two views, in sorted order
+type ViewDiff struct {
+	Added   []string // in b but not in a
+	Removed []string // in a but not in b
+	Changed []string // in both but set again after a was taken
+}
+
+// DiffViews compares two views of the cache, a taken before b
+func DiffViews(a, b View) ViewDiff {
+	var d ViewDiff
+	for _, k := range a.keys {
+		bv, ok := b.versions[k]
+		if !ok {
+			d.Removed = append(d.Removed, k)
+			continue
+		}
+		if bv != a.versions[k] {
+			d.Changed = append(d.Changed, k)
+		}
+	}
+	for _, k := range b.keys {
+		if _, ok := a.versions[k]; !ok {
+			d.Added = append(d.Added, k)
+		}
+	}
+	return d
+}
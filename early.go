@@ -0,0 +1,33 @@
+package cachestore
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+var earlyBeta atomic.Uint64 // math.Float64bits
+
+// SetEarlyExpiration enables probabilistic early expiration (XFetch) for GetOrSet,
+// entries are reloaded before expiry with probability growing as expiry comes closer
+// and with the time the loader took, scaled by beta. Zero beta disables.
+//
+// Beta of 1 is a good default, larger values reload earlier.
+func SetEarlyExpiration(beta float64) {
+	if beta < 0 {
+		beta = 0
+	}
+	earlyBeta.Store(math.Float64bits(beta))
+}
+
+func (it *item) expireEarly() bool {
+	if it.expiresAt.IsZero() || it.loadDuration <= 0 || isDeterministic() {
+		return false
+	}
+	beta := math.Float64frombits(earlyBeta.Load())
+	if beta == 0 {
+		return false
+	}
+	gap := time.Duration(float64(it.loadDuration) * beta * -math.Log(1-randFloat64()))
+	return !time.Now().Add(gap).Before(it.expiresAt)
+}
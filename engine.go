@@ -0,0 +1,496 @@
+package cachestore
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// engine is a concurrent map holding entries of a generation
+type engine interface {
+	Load(key string) (*item, bool)
+	Store(key string, it *item)
+	LoadOrStore(key string, it *item) (actual *item, loaded bool)
+	LoadAndDelete(key string) (*item, bool)
+	CompareAndSwap(key string, old, new *item) bool
+	CompareAndDelete(key string, old *item) bool
+	Range(fn func(key string, it *item) bool)
+}
+
+type Engine int32
+
+const (
+	SyncMap     Engine = iota // sync.Map, best for keys written once and read many times
+	Striped                   // 256 maps each guarded by a sync.RWMutex, best for keys rewritten often
+	Auto                      // a map guarded by a sync.RWMutex, moved to sync.Map once it grows or is contended
+	CopyOnWrite               // an immutable map replaced on every write, reads never lock, best for caches rarely written
+)
+
+func (e Engine) String() string {
+	switch e {
+	case SyncMap:
+		return "sync.Map"
+	case Striped:
+		return "striped"
+	case Auto:
+		return "auto"
+	case CopyOnWrite:
+		return "copy-on-write"
+	}
+	return "unknown"
+}
+
+var engineKind atomic.Int32
+
+// CurrentEngine returns the engine set by SetEngine
+func CurrentEngine() Engine {
+	return Engine(engineKind.Load())
+}
+
+func newEngine() engine {
+	switch Engine(engineKind.Load()) {
+	case Striped:
+		return newStripedEngine()
+	case Auto:
+		return &autoEngine{m: make(map[string]*item)}
+	case CopyOnWrite:
+		return newCOWEngine()
+	}
+	return &syncMapEngine{}
+}
+
+// SetEngine sets the engine storing entries and moves existing entries to it,
+// entries written while moving may be lost so SetEngine should be called before using the cache.
+func SetEngine(e Engine) {
+	rotateMu.Lock()
+	defer rotateMu.Unlock()
+
+	if dropTimer != nil {
+		dropTimer.Stop()
+		dropTimer = nil
+	}
+
+	engineKind.Store(int32(e))
+	m := newEngine()
+	rangeItems(func(_ engine, key string, it *item) bool {
+		m.LoadOrStore(key, it) // current generation comes first
+		return true
+	})
+	gens.Store(&generations{current: m})
+}
+
+type syncMapEngine struct {
+	m sync.Map
+}
+
+func (e *syncMapEngine) Load(key string) (*item, bool) {
+	v, ok := e.m.Load(key)
+	if !ok {
+		return nil, false
+	}
+	return v.(*item), true
+}
+
+func (e *syncMapEngine) Store(key string, it *item) {
+	e.m.Store(key, it)
+}
+
+func (e *syncMapEngine) LoadOrStore(key string, it *item) (*item, bool) {
+	v, loaded := e.m.LoadOrStore(key, it)
+	return v.(*item), loaded
+}
+
+func (e *syncMapEngine) LoadAndDelete(key string) (*item, bool) {
+	v, ok := e.m.LoadAndDelete(key)
+	if !ok {
+		return nil, false
+	}
+	return v.(*item), true
+}
+
+func (e *syncMapEngine) CompareAndSwap(key string, old, new *item) bool {
+	return e.m.CompareAndSwap(key, old, new)
+}
+
+func (e *syncMapEngine) CompareAndDelete(key string, old *item) bool {
+	return e.m.CompareAndDelete(key, old)
+}
+
+func (e *syncMapEngine) Range(fn func(key string, it *item) bool) {
+	e.m.Range(func(key, value any) bool {
+		return fn(key.(string), value.(*item))
+	})
+}
+
+const stripes = 256
+
+type stripe struct {
+	mu sync.RWMutex
+	m  map[string]*item
+}
+
+type stripedEngine struct {
+	stripes [stripes]stripe
+}
+
+func newStripedEngine() *stripedEngine {
+	var e stripedEngine
+	for i := range e.stripes {
+		e.stripes[i].m = make(map[string]*item)
+	}
+	return &e
+}
+
+func (e *stripedEngine) stripe(key string) *stripe {
+	// fnv-1a
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+	return &e.stripes[h%stripes]
+}
+
+func (e *stripedEngine) Load(key string) (*item, bool) {
+	s := e.stripe(key)
+	s.mu.RLock()
+	it, ok := s.m[key]
+	s.mu.RUnlock()
+	return it, ok
+}
+
+func (e *stripedEngine) Store(key string, it *item) {
+	s := e.stripe(key)
+	s.mu.Lock()
+	s.m[key] = it
+	s.mu.Unlock()
+}
+
+func (e *stripedEngine) LoadOrStore(key string, it *item) (*item, bool) {
+	s := e.stripe(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if old, ok := s.m[key]; ok {
+		return old, true
+	}
+	s.m[key] = it
+	return it, false
+}
+
+func (e *stripedEngine) LoadAndDelete(key string) (*item, bool) {
+	s := e.stripe(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it, ok := s.m[key]
+	if ok {
+		delete(s.m, key)
+	}
+	return it, ok
+}
+
+func (e *stripedEngine) CompareAndSwap(key string, old, new *item) bool {
+	s := e.stripe(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.m[key] != old {
+		return false
+	}
+	s.m[key] = new
+	return true
+}
+
+func (e *stripedEngine) CompareAndDelete(key string, old *item) bool {
+	s := e.stripe(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if it, ok := s.m[key]; !ok || it != old {
+		return false
+	}
+	delete(s.m, key)
+	return true
+}
+
+// Range calls fn for entries of each stripe without holding the stripe lock,
+// like sync.Map.Range it does not see a consistent snapshot.
+func (e *stripedEngine) Range(fn func(key string, it *item) bool) {
+	type entry struct {
+		key string
+		it  *item
+	}
+
+	var xs []entry
+	for i := range e.stripes {
+		s := &e.stripes[i]
+		s.mu.RLock()
+		for k, it := range s.m {
+			xs = append(xs, entry{k, it})
+		}
+		s.mu.RUnlock()
+
+		for _, x := range xs {
+			if !fn(x.key, x.it) {
+				return
+			}
+		}
+		xs = xs[:0]
+	}
+}
+
+const (
+	autoMaxEntries    = 4096 // entries before autoEngine moves to sync.Map
+	autoMaxContention = 1024 // contended writes before autoEngine moves to sync.Map
+)
+
+// autoEngine starts as a locked map, which is cheaper than sync.Map for small caches,
+// and moves every entry to a sync.Map when the map grows or writers contend.
+type autoEngine struct {
+	mu        sync.RWMutex
+	m         map[string]*item
+	contended atomic.Int64
+	big       atomic.Pointer[syncMapEngine] // set once moved
+}
+
+// lock locks e for writing and returns the sync.Map when e was moved
+func (e *autoEngine) lock() *syncMapEngine {
+	if big := e.big.Load(); big != nil {
+		return big
+	}
+	if !e.mu.TryLock() {
+		e.contended.Add(1)
+		e.mu.Lock()
+	}
+	if big := e.big.Load(); big != nil {
+		e.mu.Unlock()
+		return big
+	}
+	return nil
+}
+
+// unlock moves e to sync.Map when needed and unlocks e
+func (e *autoEngine) unlock() {
+	if len(e.m) > autoMaxEntries || e.contended.Load() > autoMaxContention {
+		big := &syncMapEngine{}
+		for k, it := range e.m {
+			big.Store(k, it)
+		}
+		e.big.Store(big)
+		e.m = nil
+	}
+	e.mu.Unlock()
+}
+
+func (e *autoEngine) Load(key string) (*item, bool) {
+	if big := e.big.Load(); big != nil {
+		return big.Load(key)
+	}
+	e.mu.RLock()
+	if big := e.big.Load(); big != nil {
+		e.mu.RUnlock()
+		return big.Load(key)
+	}
+	it, ok := e.m[key]
+	e.mu.RUnlock()
+	return it, ok
+}
+
+func (e *autoEngine) Store(key string, it *item) {
+	if big := e.lock(); big != nil {
+		big.Store(key, it)
+		return
+	}
+	e.m[key] = it
+	e.unlock()
+}
+
+func (e *autoEngine) LoadOrStore(key string, it *item) (*item, bool) {
+	if big := e.lock(); big != nil {
+		return big.LoadOrStore(key, it)
+	}
+	defer e.unlock()
+
+	if old, ok := e.m[key]; ok {
+		return old, true
+	}
+	e.m[key] = it
+	return it, false
+}
+
+func (e *autoEngine) LoadAndDelete(key string) (*item, bool) {
+	if big := e.lock(); big != nil {
+		return big.LoadAndDelete(key)
+	}
+	defer e.unlock()
+
+	it, ok := e.m[key]
+	if ok {
+		delete(e.m, key)
+	}
+	return it, ok
+}
+
+func (e *autoEngine) CompareAndSwap(key string, old, new *item) bool {
+	if big := e.lock(); big != nil {
+		return big.CompareAndSwap(key, old, new)
+	}
+	defer e.unlock()
+
+	if e.m[key] != old {
+		return false
+	}
+	e.m[key] = new
+	return true
+}
+
+func (e *autoEngine) CompareAndDelete(key string, old *item) bool {
+	if big := e.lock(); big != nil {
+		return big.CompareAndDelete(key, old)
+	}
+	defer e.unlock()
+
+	if it, ok := e.m[key]; !ok || it != old {
+		return false
+	}
+	delete(e.m, key)
+	return true
+}
+
+func (e *autoEngine) Range(fn func(key string, it *item) bool) {
+	if big := e.big.Load(); big != nil {
+		big.Range(fn)
+		return
+	}
+
+	type entry struct {
+		key string
+		it  *item
+	}
+
+	e.mu.RLock()
+	if big := e.big.Load(); big != nil {
+		e.mu.RUnlock()
+		big.Range(fn)
+		return
+	}
+	xs := make([]entry, 0, len(e.m))
+	for k, it := range e.m {
+		xs = append(xs, entry{k, it})
+	}
+	e.mu.RUnlock()
+
+	for _, x := range xs {
+		if !fn(x.key, x.it) {
+			return
+		}
+	}
+}
+
+// cowEngine keeps entries in a map never modified after it is published,
+// writes copy the map so they cost O(n).
+type cowEngine struct {
+	mu sync.Mutex // serializes writes
+	m  atomic.Pointer[map[string]*item]
+}
+
+func newCOWEngine() *cowEngine {
+	var e cowEngine
+	m := make(map[string]*item)
+	e.m.Store(&m)
+	return &e
+}
+
+// update calls fn with a copy of the map under the write lock and publishes it when fn returns true
+func (e *cowEngine) update(fn func(m map[string]*item) bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	old := *e.m.Load()
+	m := make(map[string]*item, len(old)+1)
+	for k, it := range old {
+		m[k] = it
+	}
+	if fn(m) {
+		e.m.Store(&m)
+	}
+}
+
+func (e *cowEngine) Load(key string) (*item, bool) {
+	it, ok := (*e.m.Load())[key]
+	return it, ok
+}
+
+func (e *cowEngine) Store(key string, it *item) {
+	e.update(func(m map[string]*item) bool {
+		m[key] = it
+		return true
+	})
+}
+
+func (e *cowEngine) LoadOrStore(key string, it *item) (actual *item, loaded bool) {
+	if old, ok := e.Load(key); ok {
+		return old, true
+	}
+	e.update(func(m map[string]*item) bool {
+		if old, ok := m[key]; ok {
+			actual, loaded = old, true
+			return false
+		}
+		m[key] = it
+		actual = it
+		return true
+	})
+	return actual, loaded
+}
+
+func (e *cowEngine) LoadAndDelete(key string) (it *item, ok bool) {
+	if _, ok := e.Load(key); !ok {
+		return nil, false
+	}
+	e.update(func(m map[string]*item) bool {
+		it, ok = m[key]
+		delete(m, key)
+		return ok
+	})
+	return it, ok
+}
+
+func (e *cowEngine) CompareAndSwap(key string, old, new *item) (swapped bool) {
+	if cur, _ := e.Load(key); cur != old {
+		return false
+	}
+	e.update(func(m map[string]*item) bool {
+		if m[key] != old {
+			return false
+		}
+		m[key] = new
+		swapped = true
+		return true
+	})
+	return swapped
+}
+
+func (e *cowEngine) CompareAndDelete(key string, old *item) (deleted bool) {
+	if cur, ok := e.Load(key); !ok || cur != old {
+		return false
+	}
+	e.update(func(m map[string]*item) bool {
+		if it, ok := m[key]; !ok || it != old {
+			return false
+		}
+		delete(m, key)
+		deleted = true
+		return true
+	})
+	return deleted
+}
+
+// Range iterates the map published when Range is called
+func (e *cowEngine) Range(fn func(key string, it *item) bool) {
+	for k, it := range *e.m.Load() {
+		if !fn(k, it) {
+			return
+		}
+	}
+}
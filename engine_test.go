@@ -0,0 +1,138 @@
+package cachestore
+
+import (
+	"strconv"
+	"testing"
+)
+
+var benchEngines = []Engine{SyncMap, Striped, Auto, CopyOnWrite}
+
+// newEngineOf returns a new engine of kind e without changing the package engine
+func newEngineOf(e Engine) engine {
+	old := engineKind.Swap(int32(e))
+	defer engineKind.Store(old)
+	return newEngine()
+}
+
+func benchKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = "key/" + strconv.Itoa(i)
+	}
+	return keys
+}
+
+func BenchmarkEngineLoad(b *testing.B) {
+	keys := benchKeys(1024)
+	for _, e := range benchEngines {
+		b.Run(e.String(), func(b *testing.B) {
+			m := newEngineOf(e)
+			for _, k := range keys {
+				m.Store(k, &item{})
+			}
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					m.Load(keys[i%len(keys)])
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkEngineHotRewrite rewrites a few hot keys while reading them,
+// the workload where sync.Map promotes its dirty map over and over.
+func BenchmarkEngineHotRewrite(b *testing.B) {
+	keys := benchKeys(16)
+	for _, e := range benchEngines {
+		b.Run(e.String(), func(b *testing.B) {
+			m := newEngineOf(e)
+			for _, k := range keys {
+				m.Store(k, &item{})
+			}
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					k := keys[i%len(keys)]
+					if i%4 == 0 {
+						m.Store(k, &item{})
+					} else {
+						m.Load(k)
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkEngineStoreNew(b *testing.B) {
+	for _, e := range benchEngines {
+		if e == CopyOnWrite {
+			continue // O(n) writes, not meant for write-heavy caches
+		}
+		b.Run(e.String(), func(b *testing.B) {
+			m := newEngineOf(e)
+			keys := benchKeys(b.N)
+			b.ResetTimer()
+			for _, k := range keys {
+				m.Store(k, &item{})
+			}
+		})
+	}
+}
+
+func TestAutoEngineMovesToSyncMap(t *testing.T) {
+	e := &autoEngine{m: make(map[string]*item)}
+	keys := benchKeys(autoMaxEntries + 1)
+	its := make([]*item, len(keys))
+	for i, k := range keys {
+		its[i] = &item{version: uint64(i)}
+		e.Store(k, its[i])
+		if i < autoMaxEntries && e.big.Load() != nil {
+			t.Fatalf("moved at %d entries, want after %d", i+1, autoMaxEntries)
+		}
+	}
+	if e.big.Load() == nil {
+		t.Fatalf("not moved after %d entries", len(keys))
+	}
+	for i, k := range keys {
+		if it, ok := e.Load(k); !ok || it != its[i] {
+			t.Fatalf("%s lost in the move", k)
+		}
+	}
+	if !e.CompareAndDelete(keys[0], its[0]) {
+		t.Fatal("CompareAndDelete after the move failed")
+	}
+	n := 0
+	e.Range(func(string, *item) bool { n++; return true })
+	if n != len(keys)-1 {
+		t.Fatalf("Range saw %d entries, want %d", n, len(keys)-1)
+	}
+}
+
+// BenchmarkEngineBySize compares read-mostly access over caches of growing size,
+// the auto engine moves to sync.Map past autoMaxEntries.
+func BenchmarkEngineBySize(b *testing.B) {
+	for _, size := range []int{16, 1024, 4 * autoMaxEntries} {
+		keys := benchKeys(size)
+		for _, e := range []Engine{SyncMap, Striped, Auto} {
+			b.Run(strconv.Itoa(size)+"/"+e.String(), func(b *testing.B) {
+				m := newEngineOf(e)
+				for _, k := range keys {
+					m.Store(k, &item{})
+				}
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					for i := 0; pb.Next(); i++ {
+						k := keys[i%len(keys)]
+						if i%16 == 0 {
+							m.Store(k, &item{})
+						} else {
+							m.Load(k)
+						}
+					}
+				})
+			})
+		}
+	}
+}
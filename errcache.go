@@ -0,0 +1,75 @@
+package cachestore
+
+import "time"
+
+// SetErr caches err for key with ErrorTTL, or TTL when ErrorTTL is not set,
+// Get reports a miss for cached errors while GetErr and GetOrSet return them.
+// With WithMaxServeStale the replaced value is kept and GetOrSet serves it stale instead of the error.
+func SetErr(key string, err error, opts ...Option) {
+	setErr(key, err, resolveOptions(opts))
+}
+
+func setErr(key string, err error, opt *SetOptions) {
+	if opt != nil {
+		checkTag(opt.Tag)
+	}
+	if isDisabled() {
+		return
+	}
+
+	it := item{
+		err:       err,
+		createdAt: time.Now(),
+		version:   nextVersion(),
+		schema:    currentSchema(),
+	}
+	if opt != nil {
+		it.tag = internTag(opt.Tag)
+		ttl := opt.ErrorTTL
+		if ttl == 0 {
+			ttl = opt.TTL
+		}
+		if ttl > 0 {
+			it.expiresAt = it.createdAt.Add(ttl)
+		}
+		if opt.MaxServeStale > 0 {
+			keepStale(key, &it)
+		}
+	}
+	storeItem(key, &it)
+}
+
+// keepStale keeps the value replaced by the error it, so it can still be served stale
+func keepStale(key string, it *item) {
+	prev, ok := loadItem(key)
+	if !ok {
+		return
+	}
+	if prev.err != nil {
+		prev = prev.stale
+	}
+	if prev == nil {
+		return
+	}
+	it.stale = prev
+	if it.keepUntil.Before(prev.keepUntil) {
+		it.keepUntil = prev.keepUntil
+	}
+}
+
+// GetErr returns the cached value or error for key
+func GetErr[T any](key string) (T, bool, error) {
+	if isDisabled() {
+		return *new(T), false, nil
+	}
+
+	it, ok := loadItem(key)
+	if !ok || it.Expired() {
+		return *new(T), false, nil
+	}
+	if it.err != nil {
+		return *new(T), true, it.err
+	}
+	v, ok := itemValue[T](it)
+	return v, ok, nil
+}
@@ -0,0 +1,145 @@
+package cachestore
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventSet    EventType = "set"
+	EventDelete EventType = "delete"
+	EventExpire EventType = "expire" // removed by GC after expired
+	EventEvict  EventType = "evict"  // removed to make room, see ConfigureTag
+	EventClear  EventType = "clear"  // sent after delete events of the cleared entries
+)
+
+type Event struct {
+	Time time.Time
+	Type EventType
+	Key  string
+	Tag  string
+
+	// Value is the value set or removed, included only when SetEventValues is enabled
+	Value any
+}
+
+type subscriber struct {
+	ch    chan Event
+	done  chan struct{}
+	block bool
+}
+
+var (
+	eventValues    uint32
+	subscribersMu  sync.RWMutex
+	subscribers    []*subscriber
+	hasSubscribers uint32
+	droppedEvents  atomic.Uint64
+)
+
+// Events returns a channel receiving events of every entry and a function to stop receiving them.
+//
+// When block is false events are dropped while the channel buffer is full, see DroppedEvents,
+// otherwise writes wait until the event is received.
+func Events(buffer int, block bool) (<-chan Event, func()) {
+	s := &subscriber{
+		ch:    make(chan Event, buffer),
+		done:  make(chan struct{}),
+		block: block,
+	}
+
+	subscribersMu.Lock()
+	subscribers = append(subscribers, s)
+	atomic.StoreUint32(&hasSubscribers, 1)
+	subscribersMu.Unlock()
+
+	var once sync.Once
+	return s.ch, func() {
+		once.Do(func() {
+			close(s.done)
+
+			subscribersMu.Lock()
+			defer subscribersMu.Unlock()
+
+			for i, x := range subscribers {
+				if x == s {
+					subscribers = append(subscribers[:i:i], subscribers[i+1:]...)
+					break
+				}
+			}
+			if len(subscribers) == 0 {
+				atomic.StoreUint32(&hasSubscribers, 0)
+			}
+			close(s.ch)
+		})
+	}
+}
+
+// EventsFunc calls fn with every event from a goroutine counted in Goroutines,
+// and returns a function to stop receiving them. Events are dropped while buffer events wait for fn.
+func EventsFunc(buffer int, fn func(Event)) func() {
+	ch, stop := Events(buffer, false)
+	go track(func() {
+		for e := range ch {
+			fn(e)
+		}
+	})
+	return stop
+}
+
+// SetEventValues includes values in events,
+// so consumers can keep the final state of entries removed on expiry
+func SetEventValues(value bool) {
+	if value {
+		atomic.StoreUint32(&eventValues, 1)
+	} else {
+		atomic.StoreUint32(&eventValues, 0)
+	}
+}
+
+// DroppedEvents returns the number of events dropped because a channel was full
+func DroppedEvents() uint64 {
+	return droppedEvents.Load()
+}
+
+// publish sends an event for it to every subscriber, it is nil for EventClear
+func publish(typ EventType, key string, it *item) {
+	if atomic.LoadUint32(&hasSubscribers) == 0 {
+		return
+	}
+
+	e := Event{
+		Time: time.Now(),
+		Type: typ,
+		Key:  key,
+	}
+	if it != nil {
+		e.Tag = it.tag
+		if it.err == nil && atomic.LoadUint32(&eventValues) == 1 {
+			if v, err := loadTransform(it.data); err == nil {
+				e.Value = v
+			}
+		}
+	}
+
+	subscribersMu.RLock()
+	defer subscribersMu.RUnlock()
+
+	for _, s := range subscribers {
+		if s.block {
+			select {
+			case s.ch <- e:
+			case <-s.done:
+			}
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			droppedEvents.Add(1)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package cachestore
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventsFuncStop(t *testing.T) {
+	before := Goroutines()
+	got := make(chan Event, 1)
+	stop := EventsFunc(1, func(e Event) {
+		select {
+		case got <- e:
+		default:
+		}
+	})
+	waitGoroutines(t, before+1)
+
+	Set("events-func", 1)
+	defer Delete("events-func")
+	select {
+	case e := <-got:
+		if e.Type != EventSet || e.Key != "events-func" {
+			t.Fatalf("event = %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event not received")
+	}
+
+	stop()
+	waitGoroutines(t, before)
+}
+
+func waitGoroutines(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for Goroutines() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines = %d, want %d", Goroutines(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSetEventNormalizedKey(t *testing.T) {
+	SetKeyNormalizer(strings.ToLower)
+	defer SetKeyNormalizer(nil)
+	defer Delete("event-key")
+
+	got := make(chan Event, 4)
+	stop := EventsFunc(4, func(e Event) { got <- e })
+	defer stop()
+
+	Set("Event-Key", 1)
+	Delete("Event-Key")
+	for _, typ := range []EventType{EventSet, EventDelete} {
+		select {
+		case e := <-got:
+			if e.Type != typ || e.Key != "event-key" {
+				t.Fatalf("event = %s %q, want %s of the normalized key", e.Type, e.Key, typ)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s event not received", typ)
+		}
+	}
+}
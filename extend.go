@@ -0,0 +1,56 @@
+package cachestore
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+type extendHook func(key string, m EntryMeta) time.Duration
+
+var extendOnHit atomic.Pointer[extendHook]
+
+// SetExtendOnHit calls fn on every Get and GetOrSet hit of an entry that expires,
+// the expiry is extended by the duration fn returns when positive. nil fn removes the hook.
+//
+// HitCount in m is recorded only when SetTrackAccess is enabled.
+func SetExtendOnHit(fn func(key string, m EntryMeta) time.Duration) {
+	if fn == nil {
+		extendOnHit.Store(nil)
+		return
+	}
+	h := extendHook(fn)
+	extendOnHit.Store(&h)
+}
+
+// ExtendEvery returns a SetExtendOnHit hook extending the TTL by fraction of it on every nth hit,
+// until the entry lives ceiling from when it was set.
+func ExtendEvery(n uint64, fraction float64, ceiling time.Duration) func(key string, m EntryMeta) time.Duration {
+	return func(_ string, m EntryMeta) time.Duration {
+		if n == 0 || m.HitCount == 0 || m.HitCount%n != 0 {
+			return 0
+		}
+		ttl := m.ExpiresAt.Sub(m.CreatedAt)
+		d := time.Duration(float64(ttl) * fraction)
+		if limit := m.CreatedAt.Add(ceiling).Sub(m.ExpiresAt); d > limit {
+			d = limit
+		}
+		return d
+	}
+}
+
+// extend calls the SetExtendOnHit hook for a hit of it
+func (it *item) extend(key string) {
+	h := extendOnHit.Load()
+	if h == nil || it.expiresAt.IsZero() {
+		return
+	}
+	d := (*h)(key, it.entryMeta())
+	if d <= 0 {
+		return
+	}
+	m, cur, ok := loadItemMap(normalizeKey(key))
+	if !ok || cur != it {
+		return
+	}
+	m.CompareAndSwap(normalizeKey(key), it, it.withExpiry(it.expiresAt.Add(d), it.keepUntil))
+}
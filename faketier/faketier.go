@@ -0,0 +1,174 @@
+// Package faketier provides an in-memory cachestore tier that records calls
+// and injects latency and failures, for testing code built on cachestore.Chain.
+package faketier
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Op string
+
+const (
+	OpGet    Op = "get"
+	OpSet    Op = "set"
+	OpDelete Op = "delete"
+)
+
+type Call struct {
+	Op    Op
+	Key   string
+	Value any // set value, or the value returned by a hit
+	Hit   bool
+	Err   error
+}
+
+// Tier is an in-memory tier implementing cachestore.Getter, Setter and Deleter,
+// the zero value is ready to use.
+type Tier struct {
+	mu      sync.Mutex
+	m       map[string]any
+	calls   []Call
+	latency map[Op]time.Duration
+	fail    map[Op][]error
+}
+
+func New() *Tier {
+	return &Tier{}
+}
+
+// SetLatency delays every op call by d, or until the context is done
+func (t *Tier) SetLatency(op Op, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.latency == nil {
+		t.latency = map[Op]time.Duration{}
+	}
+	t.latency[op] = d
+}
+
+// FailNext makes the next len(errs) op calls fail with errs in order
+func (t *Tier) FailNext(op Op, errs ...error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.fail == nil {
+		t.fail = map[Op][]error{}
+	}
+	t.fail[op] = append(t.fail[op], errs...)
+}
+
+// Calls returns recorded calls in order
+func (t *Tier) Calls() []Call {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]Call(nil), t.calls...)
+}
+
+// Count returns the number of recorded op calls for key, empty key counts every key
+func (t *Tier) Count(op Op, key string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := 0
+	for _, c := range t.calls {
+		if c.Op == op && (key == "" || c.Key == key) {
+			n++
+		}
+	}
+	return n
+}
+
+// Reset removes recorded calls, stored values are kept
+func (t *Tier) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.calls = nil
+}
+
+// Put stores value for key without recording a call
+func (t *Tier) Put(key string, value any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.m == nil {
+		t.m = map[string]any{}
+	}
+	t.m[key] = value
+}
+
+// Value returns the value stored for key without recording a call
+func (t *Tier) Value(key string) (any, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	v, ok := t.m[key]
+	return v, ok
+}
+
+// begin waits for the op latency and returns the injected error of the call
+func (t *Tier) begin(ctx context.Context, op Op) error {
+	t.mu.Lock()
+	d := t.latency[op]
+	var err error
+	if errs := t.fail[op]; len(errs) > 0 {
+		err = errs[0]
+		t.fail[op] = errs[1:]
+	}
+	t.mu.Unlock()
+
+	if d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+func (t *Tier) record(c Call) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.calls = append(t.calls, c)
+}
+
+func (t *Tier) Get(ctx context.Context, key string) (any, bool, error) {
+	if err := t.begin(ctx, OpGet); err != nil {
+		t.record(Call{Op: OpGet, Key: key, Err: err})
+		return nil, false, err
+	}
+	v, ok := t.Value(key)
+	t.record(Call{Op: OpGet, Key: key, Value: v, Hit: ok})
+	return v, ok, nil
+}
+
+func (t *Tier) Set(ctx context.Context, key string, value any) error {
+	err := t.begin(ctx, OpSet)
+	if err == nil {
+		t.Put(key, value)
+	}
+	t.record(Call{Op: OpSet, Key: key, Value: value, Err: err})
+	return err
+}
+
+func (t *Tier) Delete(ctx context.Context, key string) error {
+	err := t.begin(ctx, OpDelete)
+	if err == nil {
+		t.mu.Lock()
+		_, ok := t.m[key]
+		delete(t.m, key)
+		t.mu.Unlock()
+		t.record(Call{Op: OpDelete, Key: key, Hit: ok})
+		return nil
+	}
+	t.record(Call{Op: OpDelete, Key: key, Err: err})
+	return err
+}
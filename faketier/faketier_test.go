@@ -0,0 +1,129 @@
+package faketier
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/moonrhythm/cachestore"
+)
+
+var (
+	_ cachestore.Getter  = (*Tier)(nil)
+	_ cachestore.Setter  = (*Tier)(nil)
+	_ cachestore.Deleter = (*Tier)(nil)
+)
+
+func TestRecordsCalls(t *testing.T) {
+	ctx := context.Background()
+	var tier Tier // the zero value is ready to use
+
+	tier.Get(ctx, "a")
+	tier.Set(ctx, "a", 1)
+	tier.Get(ctx, "a")
+	tier.Delete(ctx, "a")
+	tier.Delete(ctx, "a")
+
+	want := []Call{
+		{Op: OpGet, Key: "a"},
+		{Op: OpSet, Key: "a", Value: 1},
+		{Op: OpGet, Key: "a", Value: 1, Hit: true},
+		{Op: OpDelete, Key: "a", Hit: true},
+		{Op: OpDelete, Key: "a"},
+	}
+	if got := tier.Calls(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got calls %+v, want %+v", got, want)
+	}
+	if n := tier.Count(OpGet, "a"); n != 2 {
+		t.Fatalf("got %d gets, want 2", n)
+	}
+	if n := tier.Count(OpDelete, ""); n != 2 {
+		t.Fatalf("got %d deletes, want 2", n)
+	}
+
+	tier.Put("b", 2)
+	tier.Reset()
+	if calls := tier.Calls(); len(calls) != 0 {
+		t.Fatalf("got calls %+v after Reset, want none", calls)
+	}
+	if v, ok := tier.Value("b"); !ok || v != 2 {
+		t.Fatalf("got %v, %v, want the value kept after Reset", v, ok)
+	}
+}
+
+func TestFailNext(t *testing.T) {
+	ctx := context.Background()
+	tier := New()
+	tier.Put("a", 1)
+
+	err1, err2 := errors.New("first"), errors.New("second")
+	tier.FailNext(OpGet, err1, err2)
+	tier.FailNext(OpSet, err1)
+	tier.FailNext(OpDelete, err2)
+
+	for _, want := range []error{err1, err2, nil} {
+		if _, _, err := tier.Get(ctx, "a"); err != want {
+			t.Fatalf("got Get error %v, want %v", err, want)
+		}
+	}
+	if err := tier.Set(ctx, "a", 2); err != err1 {
+		t.Fatalf("got Set error %v, want %v", err, err1)
+	}
+	if v, _ := tier.Value("a"); v != 1 {
+		t.Fatalf("got %v, want a failed Set to store nothing", v)
+	}
+	if err := tier.Delete(ctx, "a"); err != err2 {
+		t.Fatalf("got Delete error %v, want %v", err, err2)
+	}
+	if _, ok := tier.Value("a"); !ok {
+		t.Fatal("got a failed Delete to remove the value")
+	}
+	if calls := tier.Calls(); calls[0].Err != err1 || calls[0].Hit {
+		t.Fatalf("got first call %+v, want the failure recorded", calls[0])
+	}
+}
+
+func TestSetLatency(t *testing.T) {
+	tier := New()
+	tier.SetLatency(OpGet, 20*time.Millisecond)
+
+	start := time.Now()
+	tier.Get(context.Background(), "a")
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Fatalf("got Get after %v, want at least 20ms", d)
+	}
+
+	tier.SetLatency(OpGet, time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := tier.Get(ctx, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got error %v, want context.DeadlineExceeded", err)
+	}
+
+	start = time.Now()
+	tier.Set(context.Background(), "a", 1)
+	if d := time.Since(start); d > 10*time.Millisecond {
+		t.Fatalf("got Set after %v, want no latency on other ops", d)
+	}
+}
+
+func TestChainSkipsFailingTier(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := New(), New()
+	l2.Put("a", "v")
+	l1.FailNext(OpGet, errors.New("down"))
+
+	c := cachestore.Chain(l1, l2)
+	v, ok, err := c.Get(ctx, "a")
+	if err != nil || !ok || v != "v" {
+		t.Fatalf("got %v, %v, %v, want the value from the second tier", v, ok, err)
+	}
+	if n := l1.Count(OpSet, "a"); n != 1 {
+		t.Fatalf("got %d backfills, want 1", n)
+	}
+	if v, _ := l1.Value("a"); v != "v" {
+		t.Fatalf("got backfilled %v, want %q", v, "v")
+	}
+}
@@ -0,0 +1,103 @@
+// Package fscache caches file contents and stat results of an fs.FS in cachestore
+package fscache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/moonrhythm/cachestore"
+)
+
+var nextID atomic.Uint64
+
+// FS is an fs.FS that caches regular files read from an underlying fs.FS,
+// directories are read from the underlying fs.FS.
+type FS struct {
+	fsys   fs.FS
+	ttl    time.Duration
+	prefix string
+}
+
+// New returns an FS caching files of fsys for ttl, zero ttl caches until invalidated
+func New(fsys fs.FS, ttl time.Duration) *FS {
+	return &FS{
+		fsys:   fsys,
+		ttl:    ttl,
+		prefix: "fscache/" + strconv.FormatUint(nextID.Add(1), 10) + "/",
+	}
+}
+
+// Invalidate removes cached files which path has prefix and returns the number of removed entries
+func (f *FS) Invalidate(prefix string) int {
+	return cachestore.DeletePrefix(f.prefix + prefix)
+}
+
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	return cachestore.GetOrSet(context.Background(), f.prefix+name+"|stat", func(context.Context) (fs.FileInfo, error) {
+		return fs.Stat(f.fsys, name)
+	}, cachestore.WithTTL(f.ttl))
+}
+
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	b, err := f.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Clone(b), nil
+}
+
+// readFile returns the cached content, which must not be modified
+func (f *FS) readFile(name string) ([]byte, error) {
+	return cachestore.GetOrSet(context.Background(), f.prefix+name+"|data", func(context.Context) ([]byte, error) {
+		return fs.ReadFile(f.fsys, name)
+	}, cachestore.WithTTL(f.ttl))
+}
+
+func (f *FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	info, err := f.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return f.fsys.Open(name)
+	}
+	b, err := f.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return &file{Reader: bytes.NewReader(b), info: info}, nil
+}
+
+// file is an opened cached file, it implements io.Seeker and io.ReaderAt for http.FS
+type file struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *file) Close() error {
+	return nil
+}
+
+var (
+	_ fs.StatFS     = (*FS)(nil)
+	_ fs.ReadFileFS = (*FS)(nil)
+	_ io.Seeker     = (*file)(nil)
+)
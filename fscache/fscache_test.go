@@ -0,0 +1,117 @@
+package fscache
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestReadFileRoundTrip(t *testing.T) {
+	m := fstest.MapFS{"a.txt": {Data: []byte("hello")}}
+	f := New(m, 0)
+	t.Cleanup(func() { f.Invalidate("") })
+
+	b, err := f.ReadFile("a.txt")
+	if err != nil || string(b) != "hello" {
+		t.Fatalf("got %q, %v, want %q", b, err, "hello")
+	}
+	b[0] = 'j' // the cached content is not shared
+
+	file, err := f.Open("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	b, err = io.ReadAll(file)
+	if err != nil || string(b) != "hello" {
+		t.Fatalf("got opened %q, %v, want %q", b, err, "hello")
+	}
+	if info, _ := file.Stat(); info.Size() != 5 {
+		t.Fatalf("got size %d, want 5", info.Size())
+	}
+
+	m["a.txt"] = &fstest.MapFile{Data: []byte("changed")}
+	b, err = f.ReadFile("a.txt")
+	if err != nil || string(b) != "hello" {
+		t.Fatalf("got %q, %v, want the cached %q", b, err, "hello")
+	}
+
+	if n := f.Invalidate("a.txt"); n != 2 {
+		t.Fatalf("got %d invalidated entries, want data and stat", n)
+	}
+	if b, _ := f.ReadFile("a.txt"); string(b) != "changed" {
+		t.Fatalf("got %q after Invalidate, want %q", b, "changed")
+	}
+}
+
+func TestReadFileExpiry(t *testing.T) {
+	m := fstest.MapFS{"a.txt": {Data: []byte("old")}}
+	f := New(m, 10*time.Millisecond)
+	t.Cleanup(func() { f.Invalidate("") })
+
+	if b, _ := f.ReadFile("a.txt"); string(b) != "old" {
+		t.Fatalf("got %q, want %q", b, "old")
+	}
+	m["a.txt"] = &fstest.MapFile{Data: []byte("new")}
+	time.Sleep(20 * time.Millisecond)
+	if b, _ := f.ReadFile("a.txt"); string(b) != "new" {
+		t.Fatalf("got %q after ttl, want %q", b, "new")
+	}
+}
+
+// corruptFS fails reading files while corrupt is set
+type corruptFS struct {
+	fstest.MapFS
+	corrupt bool
+}
+
+var errCorrupt = errors.New("corrupt file")
+
+func (c *corruptFS) ReadFile(name string) ([]byte, error) {
+	if c.corrupt {
+		return nil, errCorrupt
+	}
+	return c.MapFS.ReadFile(name)
+}
+
+func TestReadFileCorrupt(t *testing.T) {
+	c := &corruptFS{MapFS: fstest.MapFS{"a.txt": {Data: []byte("hello")}}, corrupt: true}
+	f := New(c, 0)
+	t.Cleanup(func() { f.Invalidate("") })
+
+	if _, err := f.ReadFile("a.txt"); !errors.Is(err, errCorrupt) {
+		t.Fatalf("got error %v, want %v", err, errCorrupt)
+	}
+	if _, err := f.Open("a.txt"); !errors.Is(err, errCorrupt) {
+		t.Fatalf("got open error %v, want %v", err, errCorrupt)
+	}
+
+	// errors are not cached
+	c.corrupt = false
+	if b, err := f.ReadFile("a.txt"); err != nil || string(b) != "hello" {
+		t.Fatalf("got %q, %v, want %q", b, err, "hello")
+	}
+
+	if _, err := f.ReadFile("missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("got error %v, want fs.ErrNotExist", err)
+	}
+	if _, err := f.ReadFile("../a.txt"); !errors.Is(err, fs.ErrInvalid) {
+		t.Fatalf("got error %v, want fs.ErrInvalid", err)
+	}
+}
+
+func TestOpenDir(t *testing.T) {
+	f := New(fstest.MapFS{"dir/a.txt": {Data: []byte("a")}}, 0)
+	t.Cleanup(func() { f.Invalidate("") })
+
+	entries, err := fs.ReadDir(f, "dir")
+	if err != nil || len(entries) != 1 || entries[0].Name() != "a.txt" {
+		t.Fatalf("got %v, %v, want a.txt", entries, err)
+	}
+	if err := fstest.TestFS(f, "dir/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,206 @@
+package cachestore
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type GCResult struct {
+	Scanned  int
+	Removed  int
+	Duration time.Duration
+}
+
+const gcHistorySize = 16
+
+var (
+	gcHistoryMu sync.Mutex
+	gcHistory   []GCResult
+	gcLastRun   time.Time
+	gcLoops     int
+	gcInterval  time.Duration
+)
+
+func recordGC(r GCResult) {
+	gcHistoryMu.Lock()
+	defer gcHistoryMu.Unlock()
+
+	gcLastRun = time.Now()
+	if len(gcHistory) == gcHistorySize {
+		copy(gcHistory, gcHistory[1:])
+		gcHistory = gcHistory[:gcHistorySize-1]
+	}
+	gcHistory = append(gcHistory, r)
+}
+
+// GCResults returns results of the last GC runs, oldest first
+func GCResults() []GCResult {
+	gcHistoryMu.Lock()
+	defer gcHistoryMu.Unlock()
+
+	return append([]GCResult(nil), gcHistory...)
+}
+
+type GCState struct {
+	Running      bool          // a GC loop is running
+	Interval     time.Duration // current interval of the last started loop
+	LastRun      time.Time
+	LastDuration time.Duration
+}
+
+// GCStatus reports whether a GC loop is running and the last GC run
+func GCStatus() GCState {
+	gcHistoryMu.Lock()
+	defer gcHistoryMu.Unlock()
+
+	st := GCState{
+		Running: gcLoops > 0,
+		LastRun: gcLastRun,
+	}
+	if st.Running {
+		st.Interval = gcInterval
+	}
+	if n := len(gcHistory); n > 0 {
+		st.LastDuration = gcHistory[n-1].Duration
+	}
+	return st
+}
+
+func setGCLoopInterval(d time.Duration) {
+	gcHistoryMu.Lock()
+	gcInterval = d
+	gcHistoryMu.Unlock()
+}
+
+// startGCLoop records a running loop and returns a function to be called when the loop ends
+func startGCLoop(d time.Duration) func() {
+	gcHistoryMu.Lock()
+	gcLoops++
+	gcInterval = d
+	gcHistoryMu.Unlock()
+
+	return func() {
+		gcHistoryMu.Lock()
+		gcLoops--
+		gcHistoryMu.Unlock()
+	}
+}
+
+func GC() GCResult {
+	start := time.Now()
+	var r GCResult
+	rangeItems(func(m engine, key string, it *item) bool {
+		r.Scanned++
+		if it.Expired() && time.Now().After(it.keepUntil) {
+			if m.CompareAndDelete(key, it) {
+				r.Removed++
+				removed(key, it, EventExpire)
+			}
+		}
+		return true
+	})
+	now := time.Now()
+	pruneBuckets(now)
+	pruneLeases(now)
+	pruneKeyTrie()
+	r.Duration = time.Since(start)
+	recordGC(r)
+	return r
+}
+
+type GCOptions struct {
+	Immediate bool             // run GC when the loop starts
+	Jitter    float64          // randomize each interval by up to this fraction of it, to spread GC across replicas
+	OnRun     func(r GCResult) // called after each run
+}
+
+// RunGCInterval runs GC every d until ctx is done
+func RunGCInterval(ctx context.Context, d time.Duration, opts ...GCOptions) {
+	if d <= 0 {
+		return
+	}
+	var opt GCOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	defer startGCLoop(d)()
+
+	run := func() {
+		r := GC()
+		if opt.OnRun != nil {
+			opt.OnRun(r)
+		}
+	}
+	if opt.Immediate {
+		run()
+	}
+
+	t := time.NewTimer(jitter(d, opt.Jitter))
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			run()
+			t.Reset(jitter(d, opt.Jitter))
+		}
+	}
+}
+
+// jitter returns d changed by a random amount up to fraction of d
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 || isDeterministic() {
+		return d
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+	j := time.Duration((randFloat64()*2 - 1) * fraction * float64(d))
+	if d+j <= 0 {
+		return d
+	}
+	return d + j
+}
+
+// RunGCAdaptive runs GC with interval between minInterval and maxInterval,
+// the interval is doubled when GC removes nothing and halved when
+// more than a quarter of scanned entries were expired.
+func RunGCAdaptive(ctx context.Context, minInterval, maxInterval time.Duration) {
+	if minInterval <= 0 || maxInterval < minInterval {
+		return
+	}
+	d := minInterval
+	defer startGCLoop(d)()
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			r := GC()
+			d = nextGCInterval(d, r, minInterval, maxInterval)
+			setGCLoopInterval(d)
+			t.Reset(d)
+		}
+	}
+}
+
+func nextGCInterval(d time.Duration, r GCResult, minInterval, maxInterval time.Duration) time.Duration {
+	switch {
+	case r.Removed == 0:
+		d *= 2
+	case r.Removed*4 > r.Scanned:
+		d /= 2
+	}
+	if d < minInterval {
+		d = minInterval
+	}
+	if d > maxInterval {
+		d = maxInterval
+	}
+	return d
+}
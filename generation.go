@@ -0,0 +1,55 @@
+package cachestore
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type generations struct {
+	current  engine
+	previous engine
+}
+
+var (
+	gens      atomic.Pointer[generations]
+	rotateMu  sync.Mutex
+	dropTimer *time.Timer
+)
+
+func init() {
+	gens.Store(&generations{current: newEngine()})
+}
+
+// Rotate starts a new generation, new entries are stored in the new generation
+// while reads fall back to the previous generation until it is dropped after grace.
+//
+// Entries older than the previous generation are dropped immediately.
+func Rotate(grace time.Duration) {
+	rotateMu.Lock()
+	defer rotateMu.Unlock()
+
+	if dropTimer != nil {
+		dropTimer.Stop()
+		dropTimer = nil
+	}
+
+	g := gens.Load()
+	if grace <= 0 {
+		gens.Store(&generations{current: newEngine()})
+		return
+	}
+
+	prev := g.current
+	gens.Store(&generations{current: newEngine(), previous: prev})
+	dropTimer = afterFunc(grace, func() {
+		rotateMu.Lock()
+		defer rotateMu.Unlock()
+
+		g := gens.Load()
+		if g.previous != prev {
+			return
+		}
+		gens.Store(&generations{current: g.current})
+	})
+}
@@ -0,0 +1,53 @@
+package cachestore
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+var (
+	goroutines    atomic.Int64
+	maxGoroutines atomic.Int64
+)
+
+// SetMaxGoroutines caps goroutines started by loaders, zero means no cap.
+//
+// When the cap is reached loaders run in the calling goroutine,
+// so GetOrSetWithTimeout can not abandon them.
+func SetMaxGoroutines(n int) {
+	maxGoroutines.Store(int64(n))
+}
+
+// Goroutines returns the number of goroutines currently running package work,
+// including loaders, timer callbacks and the GC loop started by ApplyConfig.
+func Goroutines() int {
+	return int(goroutines.Load())
+}
+
+// spawn runs fn in a new goroutine unless the cap is reached, it reports whether fn was started
+func spawn(fn func()) bool {
+	n := goroutines.Add(1)
+	if limit := maxGoroutines.Load(); limit > 0 && n > limit {
+		goroutines.Add(-1)
+		return false
+	}
+	go func() {
+		defer goroutines.Add(-1)
+		fn()
+	}()
+	return true
+}
+
+// track runs fn counted in Goroutines, regardless of the cap
+func track(fn func()) {
+	goroutines.Add(1)
+	defer goroutines.Add(-1)
+	fn()
+}
+
+// afterFunc is time.AfterFunc that counts fn in Goroutines while it runs
+func afterFunc(d time.Duration, fn func()) *time.Timer {
+	return time.AfterFunc(d, func() {
+		track(fn)
+	})
+}
@@ -0,0 +1,119 @@
+package cachestore
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"time"
+)
+
+// WriteSnapshot writes live entries to w in the format of StartRecording, for Replay.
+// Values are encoded with encoding/json, entries which value can not be encoded are skipped
+// and the first such error is returned after writing every other entry.
+func WriteSnapshot(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	var firstErr error
+	var writeErr error
+	seen := make(map[string]struct{})
+	rangeItems(func(_ engine, key string, it *item) bool {
+		if _, ok := seen[key]; ok { // shadowed by current generation
+			return true
+		}
+		seen[key] = struct{}{}
+		if it.Expired() || it.err != nil || it.Outdated() { // a replay would store outdated entries as current
+			return true
+		}
+		value, err := loadTransform(it.data)
+		if err != nil {
+			return true
+		}
+		e := recordedEvent{
+			Time: it.createdAt,
+			Op:   "set",
+			Key:  key,
+			Tag:  it.tag,
+		}
+		if !it.expiresAt.IsZero() {
+			e.TTL = it.expiresAt.Sub(it.createdAt)
+		}
+		e.Value, err = json.Marshal(value)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return true
+		}
+		if name, version, ok := registeredTypeOf(value); ok {
+			e.Type, e.Version = name, version
+		}
+		writeErr = enc.Encode(e)
+		return writeErr == nil
+	})
+	if writeErr != nil {
+		return writeErr
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return firstErr
+}
+
+// ServeHandoff writes a snapshot to every connection accepted from l until ctx is done,
+// for a new process to receive with ReceiveHandoff during a restart, l is closed when ServeHandoff returns.
+func ServeHandoff(ctx context.Context, l net.Listener) error {
+	defer l.Close()
+	stop := context.AfterFunc(ctx, func() { l.Close() })
+	defer stop()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go track(func() {
+			defer conn.Close()
+			if d, ok := ctx.Deadline(); ok {
+				conn.SetDeadline(d)
+			}
+			WriteSnapshot(conn)
+		})
+	}
+}
+
+// ReceiveHandoff connects to a process running ServeHandoff at address and replays its snapshot,
+// ctx bounds the whole transfer, entries received before ctx is done are kept.
+//
+// Without opts entries keep their recorded expiry, see PreserveDeadline.
+func ReceiveHandoff(ctx context.Context, network, address string, decode func(key string, value json.RawMessage) (any, error), opts ...ReplayOptions) error {
+	if len(opts) == 0 {
+		opts = []ReplayOptions{{TTL: PreserveDeadline}}
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if dl, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(dl)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Unix(1, 0))
+	})
+	defer stop()
+
+	err = Replay(conn, decode, opts...)
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
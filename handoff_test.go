@@ -0,0 +1,111 @@
+package cachestore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeString(_ string, v json.RawMessage) (any, error) {
+	var s string
+	err := json.Unmarshal(v, &s)
+	return s, err
+}
+
+func TestWriteSnapshotSkipsOutdated(t *testing.T) {
+	defer SetCurrentSchema(currentSchema())
+	key := t.Name()
+	defer DeleteKeys(key+"/old", key+"/new")
+
+	Set(key+"/old", "old")
+	SetCurrentSchema(currentSchema() + 1)
+	Set(key+"/new", "new")
+
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), key+"/old") {
+		t.Fatal("snapshot holds an entry outdated by schema")
+	}
+	if !strings.Contains(buf.String(), key+"/new") {
+		t.Fatal("snapshot misses a current entry")
+	}
+}
+
+func TestHandoffRoundTrip(t *testing.T) {
+	key := t.Name()
+	defer Delete(key)
+	Set(key, "value", WithTTL(time.Minute))
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- ServeHandoff(ctx, l) }()
+
+	var buf bytes.Buffer
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.ReadFrom(conn)
+	conn.Close()
+	Delete(key)
+
+	if err := Replay(&buf, decodeString, ReplayOptions{TTL: PreserveDeadline}); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := Get[string](key); !ok || v != "value" {
+		t.Fatalf("Get after handoff = %q, %v", v, ok)
+	}
+	if ttl := MTTL([]string{key})[key]; ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("TTL after handoff = %v, want the recorded expiry", ttl)
+	}
+
+	cancel()
+	if err := <-served; err != nil {
+		t.Fatalf("ServeHandoff = %v", err)
+	}
+}
+
+func TestReceiveHandoff(t *testing.T) {
+	key := t.Name()
+	defer Delete(key)
+	Set(key, "value")
+	var snap bytes.Buffer
+	if err := WriteSnapshot(&snap); err != nil {
+		t.Fatal(err)
+	}
+	Delete(key)
+
+	// a listener serving the captured snapshot, as the old process would
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write(snap.Bytes())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := ReceiveHandoff(ctx, "tcp", l.Addr().String(), decodeString); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := Get[string](key); !ok || v != "value" {
+		t.Fatalf("Get after ReceiveHandoff = %q, %v", v, ok)
+	}
+}
@@ -0,0 +1,119 @@
+package cachestore
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+type HedgeStats struct {
+	Reads     uint64 // reads of the tier
+	Hedged    uint64 // reads that went past the threshold and started the loader
+	LoaderWon uint64 // hedged reads answered by the loader
+}
+
+type Hedged struct {
+	g      Getter
+	after  time.Duration
+	loader func(ctx context.Context, key string) (any, error)
+
+	reads     uint64
+	hedged    uint64
+	loaderWon uint64
+}
+
+// Hedge returns a tier reading g that also calls loader when g does not answer within after,
+// the first to finish wins and the other is canceled.
+//
+// A miss or error from g after the loader started waits for the loader.
+func Hedge(g Getter, after time.Duration, loader func(ctx context.Context, key string) (any, error)) *Hedged {
+	return &Hedged{g: g, after: after, loader: loader}
+}
+
+type hedgeResult struct {
+	v      any
+	ok     bool
+	err    error
+	loader bool
+}
+
+func (h *Hedged) Get(ctx context.Context, key string) (any, bool, error) {
+	atomic.AddUint64(&h.reads, 1)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// when the goroutine cap is reached g is read in the calling goroutine, without hedging
+	ch := make(chan hedgeResult, 2)
+	read := func() {
+		v, ok, err := h.g.Get(ctx, key)
+		ch <- hedgeResult{v: v, ok: ok, err: err}
+	}
+	if !spawn(read) {
+		read()
+	}
+
+	t := time.NewTimer(h.after)
+	defer t.Stop()
+
+	select {
+	case r := <-ch:
+		return r.v, r.ok, r.err
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	case <-t.C:
+	}
+
+	atomic.AddUint64(&h.hedged, 1)
+	hedge := func() {
+		v, err := h.loader(ctx, key)
+		ch <- hedgeResult{v: v, ok: err == nil, err: err, loader: true}
+	}
+	if !spawn(hedge) {
+		hedge()
+	}
+
+	var first *hedgeResult
+	for i := 0; i < 2; i++ {
+		var r hedgeResult
+		select {
+		case r = <-ch:
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+		if r.ok {
+			if r.loader {
+				atomic.AddUint64(&h.loaderWon, 1)
+			}
+			return r.v, true, nil
+		}
+		if first == nil || (first.err == nil && r.err != nil) {
+			first = &r
+		}
+	}
+	return nil, false, first.err
+}
+
+// Set passes through to g when it implements Setter
+func (h *Hedged) Set(ctx context.Context, key string, value any) error {
+	if s, ok := h.g.(Setter); ok {
+		return s.Set(ctx, key, value)
+	}
+	return nil
+}
+
+// Delete passes through to g when it implements Deleter
+func (h *Hedged) Delete(ctx context.Context, key string) error {
+	if d, ok := h.g.(Deleter); ok {
+		return d.Delete(ctx, key)
+	}
+	return nil
+}
+
+func (h *Hedged) Stats() HedgeStats {
+	return HedgeStats{
+		Reads:     atomic.LoadUint64(&h.reads),
+		Hedged:    atomic.LoadUint64(&h.hedged),
+		LoaderWon: atomic.LoadUint64(&h.loaderWon),
+	}
+}
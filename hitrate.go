@@ -0,0 +1,102 @@
+package cachestore
+
+import (
+	"math"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type hitRate struct {
+	pattern  string
+	halfLife time.Duration
+
+	mu    sync.Mutex
+	hits  float64
+	total float64
+	last  time.Time
+}
+
+func (r *hitRate) observe(t time.Time, hit bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.last.IsZero() {
+		f := math.Exp2(-float64(t.Sub(r.last)) / float64(r.halfLife))
+		r.hits *= f
+		r.total *= f
+	}
+	r.last = t
+	r.total++
+	if hit {
+		r.hits++
+	}
+}
+
+func (r *hitRate) rate() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.total == 0 {
+		return 0
+	}
+	return r.hits / r.total
+}
+
+var (
+	hitRatesMu sync.Mutex
+	hitRates   atomic.Pointer[[]*hitRate]
+)
+
+// TrackHitRate tracks hit rate of Get and GetOrSet for keys matching pattern in path.Match syntax,
+// older calls weigh half as much after each halfLife.
+func TrackHitRate(pattern string, halfLife time.Duration) {
+	if halfLife <= 0 {
+		return
+	}
+
+	hitRatesMu.Lock()
+	defer hitRatesMu.Unlock()
+
+	var rs []*hitRate
+	if p := hitRates.Load(); p != nil {
+		rs = append(rs, *p...)
+	}
+	for _, r := range rs {
+		if r.pattern == pattern {
+			return
+		}
+	}
+	rs = append(rs, &hitRate{pattern: pattern, halfLife: halfLife})
+	hitRates.Store(&rs)
+}
+
+// HitRates returns the decayed hit rate of each tracked pattern
+func HitRates() map[string]float64 {
+	m := map[string]float64{}
+	if p := hitRates.Load(); p != nil {
+		for _, r := range *p {
+			m[r.pattern] = r.rate()
+		}
+	}
+	return m
+}
+
+func trackHit(key string, hit bool) {
+	recordWindow(hit)
+
+	p := hitRates.Load()
+	if p == nil {
+		return
+	}
+	var now time.Time
+	for _, r := range *p {
+		if ok, _ := path.Match(r.pattern, key); ok {
+			if now.IsZero() {
+				now = time.Now()
+			}
+			r.observe(now, hit)
+		}
+	}
+}
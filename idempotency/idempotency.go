@@ -0,0 +1,140 @@
+// Package idempotency stores results of requests by idempotency key in cachestore,
+// so a retried request gets the stored result instead of running again.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/moonrhythm/cachestore"
+)
+
+const keyPrefix = "idempotency/"
+
+// Fingerprint returns a key identifying a request from its parts, such as the idempotency key header,
+// the user, method, path and body
+func Fingerprint(parts ...[]byte) string {
+	h := sha256.New()
+	for _, p := range parts {
+		var n [8]byte
+		binary.LittleEndian.PutUint64(n[:], uint64(len(p))) // parts can not run together
+		h.Write(n[:])
+		h.Write(p)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Do calls fn once for key within ttl and returns its result to every call,
+// concurrent calls wait for the first one. replayed reports whether this call did not run fn,
+// because the result was stored by an earlier call or it waited for a concurrent one.
+//
+// An error returned by fn is not stored, the next call runs fn again.
+//
+// Results are stored in cachestore, so the guarantee holds only while it stores them:
+// while cachestore is disabled, or when SetSampling bypasses keys starting with "idempotency/",
+// every call runs fn.
+func Do[T any](ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) (T, error)) (v T, replayed bool, err error) {
+	ran := false // only the loader of the call running fn sets it
+	r, err := cachestore.GetOrSetResult(ctx, keyPrefix+key, func(ctx context.Context) (T, error) {
+		ran = true
+		return fn(ctx)
+	}, cachestore.WithTTL(ttl))
+	if err != nil {
+		return v, false, err
+	}
+	return r.Value, !ran, nil
+}
+
+// Forget removes the stored result for key
+func Forget(key string) bool {
+	return cachestore.Delete(keyPrefix + key)
+}
+
+// Response is a captured http response
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+func (r *Response) write(w http.ResponseWriter) {
+	h := w.Header()
+	for k, v := range r.Header {
+		h[k] = append([]string(nil), v...)
+	}
+	w.WriteHeader(r.StatusCode)
+	w.Write(r.Body)
+}
+
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header { return r.header }
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(b)
+}
+
+// serverError carries a 5xx response, which is returned but not stored
+type serverError struct {
+	resp *Response
+}
+
+func (e serverError) Error() string {
+	return http.StatusText(e.resp.StatusCode)
+}
+
+// Middleware stores responses of requests by the key returned by key for ttl
+// and replays them to retried requests, requests with empty key are not stored.
+// Responses with 5xx status are not stored so a retry runs the handler again.
+func Middleware(ttl time.Duration, key func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if k == "" {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			resp, replayed, err := Do(r.Context(), k, ttl, func(ctx context.Context) (*Response, error) {
+				rec := &recorder{header: http.Header{}}
+				h.ServeHTTP(rec, r.WithContext(ctx))
+				if rec.status == 0 {
+					rec.status = http.StatusOK
+				}
+				resp := &Response{StatusCode: rec.status, Header: rec.header, Body: rec.body.Bytes()}
+				if resp.StatusCode >= 500 {
+					return nil, serverError{resp}
+				}
+				return resp, nil
+			})
+			if err != nil {
+				if se, ok := err.(serverError); ok {
+					se.resp.write(w)
+					return
+				}
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+			if replayed {
+				w.Header().Set("Idempotent-Replayed", "true")
+			}
+			resp.write(w)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/moonrhythm/cachestore"
+)
+
+func TestDoReplays(t *testing.T) {
+	key := t.Name()
+	defer Forget(key)
+	ctx := context.Background()
+
+	calls := 0
+	fn := func(context.Context) (int, error) {
+		calls++
+		return calls, nil
+	}
+	if v, replayed, err := Do(ctx, key, time.Minute, fn); err != nil || replayed || v != 1 {
+		t.Fatalf("first Do = %v, %v, %v", v, replayed, err)
+	}
+	if v, replayed, err := Do(ctx, key, time.Minute, fn); err != nil || !replayed || v != 1 {
+		t.Fatalf("second Do = %v, %v, %v, want the stored result replayed", v, replayed, err)
+	}
+}
+
+func TestDoErrorNotStored(t *testing.T) {
+	key := t.Name()
+	defer Forget(key)
+	ctx := context.Background()
+
+	_, _, err := Do(ctx, key, time.Minute, func(context.Context) (int, error) {
+		return 0, errors.New("failed")
+	})
+	if err == nil {
+		t.Fatal("error not returned")
+	}
+	v, replayed, err := Do(ctx, key, time.Minute, func(context.Context) (int, error) {
+		return 2, nil
+	})
+	if err != nil || replayed || v != 2 {
+		t.Fatalf("Do after error = %v, %v, %v, want fn run again", v, replayed, err)
+	}
+}
+
+func TestDoJoinedCallsReplayed(t *testing.T) {
+	key := t.Name()
+	defer Forget(key)
+	ctx := context.Background()
+
+	var runs atomic.Int32
+	release := make(chan struct{})
+	fn := func(context.Context) (int, error) {
+		runs.Add(1)
+		<-release
+		return 1, nil
+	}
+
+	const n = 4
+	var wg sync.WaitGroup
+	var ran atomic.Int32
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, replayed, err := Do(ctx, key, time.Minute, fn); err == nil && !replayed {
+				ran.Add(1)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if runs.Load() != 1 || ran.Load() != 1 {
+		t.Fatalf("fn ran %d times and %d calls reported not replayed, want 1 and 1", runs.Load(), ran.Load())
+	}
+}
+
+func TestDoDisabledRunsEveryCall(t *testing.T) {
+	cachestore.SetDisable(true)
+	defer cachestore.SetDisable(false)
+	ctx := context.Background()
+
+	calls := 0
+	fn := func(context.Context) (int, error) {
+		calls++
+		return calls, nil
+	}
+	Do(ctx, t.Name(), time.Minute, fn)
+	if _, replayed, _ := Do(ctx, t.Name(), time.Minute, fn); replayed || calls != 2 {
+		t.Fatalf("disabled: replayed %v after %d calls, want fn run every call", replayed, calls)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	defer Forget("mw")
+	calls := 0
+	h := Middleware(time.Minute, func(r *http.Request) string {
+		return r.Header.Get("Idempotency-Key")
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-Call", "1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}))
+
+	do := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set("Idempotency-Key", "mw")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	first := do()
+	second := do()
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != "created" || second.Header().Get("X-Call") != "1" {
+		t.Fatalf("replayed response = %d %q %v", second.Code, second.Body.String(), second.Header())
+	}
+	if first.Header().Get("Idempotent-Replayed") != "" || second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatal("Idempotent-Replayed header not set only on the replay")
+	}
+}
+
+func TestMiddlewareServerErrorNotStored(t *testing.T) {
+	defer Forget("mw-5xx")
+	calls := 0
+	h := Middleware(time.Minute, func(*http.Request) string { return "mw-5xx" })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+		if w.Code != http.StatusBadGateway {
+			t.Fatalf("status = %d", w.Code)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("handler called %d times, want 2", calls)
+	}
+}
@@ -0,0 +1,115 @@
+package cachestore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"math"
+	"reflect"
+	"sync/atomic"
+)
+
+const checksumDepth = 8
+
+var debugImmutable uint32
+
+// SetDebugImmutable enables checking that cached values are not mutated in place,
+// a checksum of the value is computed on Set and Get panics when the value no longer matches it.
+//
+// Computing the checksum walks the whole value, use only in development.
+func SetDebugImmutable(value bool) {
+	if value {
+		atomic.StoreUint32(&debugImmutable, 1)
+	} else {
+		atomic.StoreUint32(&debugImmutable, 0)
+	}
+}
+
+func isDebugImmutable() bool {
+	return atomic.LoadUint32(&debugImmutable) == 1
+}
+
+// checksum returns the checksum of v, it is never zero
+func checksum(v any) uint64 {
+	h := fnv.New64a()
+	if v != nil {
+		hashValue(h, reflect.ValueOf(v), checksumDepth)
+	}
+	if s := h.Sum64(); s != 0 {
+		return s
+	}
+	return 1
+}
+
+func hashValue(h hash.Hash64, v reflect.Value, depth int) {
+	if depth < 0 {
+		return
+	}
+
+	var b [8]byte
+	writeUint := func(n uint64) {
+		binary.LittleEndian.PutUint64(b[:], n)
+		h.Write(b[:])
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			writeUint(1)
+		} else {
+			writeUint(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeUint(math.Float64bits(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		writeUint(math.Float64bits(real(c)))
+		writeUint(math.Float64bits(imag(c)))
+	case reflect.String:
+		writeUint(uint64(v.Len()))
+		h.Write([]byte(v.String()))
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			writeUint(0)
+			return
+		}
+		writeUint(1)
+		hashValue(h, v.Elem(), depth-1)
+	case reflect.Slice, reflect.Array:
+		writeUint(uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			hashValue(h, v.Index(i), depth-1)
+		}
+	case reflect.Map:
+		// entries are combined regardless of iteration order
+		writeUint(uint64(v.Len()))
+		var sum uint64
+		iter := v.MapRange()
+		for iter.Next() {
+			eh := fnv.New64a()
+			hashValue(eh, iter.Key(), depth-1)
+			hashValue(eh, iter.Value(), depth-1)
+			sum += eh.Sum64()
+		}
+		writeUint(sum)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			hashValue(h, v.Field(i), depth)
+		}
+	}
+}
+
+// checkImmutable panics when the value of it was mutated after it was set
+func (it *item) checkImmutable(key string) {
+	if it.checksum == 0 || !isDebugImmutable() {
+		return
+	}
+	if checksum(it.data) != it.checksum {
+		panic(fmt.Sprintf("cachestore: value of %q was mutated after Set", key))
+	}
+}
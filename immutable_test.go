@@ -0,0 +1,30 @@
+package cachestore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCoalesceDeepValues(t *testing.T) {
+	key := t.Name()
+	defer Delete(key)
+
+	nested := func(leaf string) map[string]any {
+		var v any = leaf
+		for i := 0; i < checksumDepth+2; i++ {
+			v = map[string]any{"x": v}
+		}
+		return v.(map[string]any)
+	}
+	Set(key, nested("a"), WithCoalesce(time.Minute))
+	Set(key, nested("b"), WithCoalesce(time.Minute))
+
+	m, _ := Get[map[string]any](key)
+	var v any = m
+	for i := 0; i < checksumDepth+2; i++ {
+		v = v.(map[string]any)["x"]
+	}
+	if v != "b" {
+		t.Fatalf("leaf = %v, want b written over a in the coalesce window", v)
+	}
+}
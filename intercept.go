@@ -0,0 +1,65 @@
+package cachestore
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Call is an operation passed through interceptors,
+// an interceptor may change it before calling next.
+type Call struct {
+	Op      AuditOp // OpGet, OpSet or OpDelete
+	Key     string
+	Value   any         // value to set for OpSet
+	Options *SetOptions // options for OpSet, may be nil
+}
+
+// Invoker runs c, it returns the value and whether it was found for OpGet,
+// and whether the key existed for OpDelete.
+type Invoker func(c *Call) (any, bool)
+
+// Interceptor wraps Get, Set and Delete
+type Interceptor interface {
+	Intercept(c *Call, next Invoker) (any, bool)
+}
+
+type InterceptorFunc func(c *Call, next Invoker) (any, bool)
+
+func (f InterceptorFunc) Intercept(c *Call, next Invoker) (any, bool) {
+	return f(c, next)
+}
+
+var (
+	interceptorsMu sync.Mutex
+	interceptors   atomic.Pointer[[]Interceptor]
+)
+
+// Use appends interceptors, the first interceptor is the outermost
+func Use(ics ...Interceptor) {
+	interceptorsMu.Lock()
+	defer interceptorsMu.Unlock()
+
+	var xs []Interceptor
+	if p := interceptors.Load(); p != nil {
+		xs = append(xs, *p...)
+	}
+	xs = append(xs, ics...)
+	interceptors.Store(&xs)
+}
+
+func hasInterceptors() bool {
+	p := interceptors.Load()
+	return p != nil && len(*p) > 0
+}
+
+func intercept(c *Call, fn Invoker) (any, bool) {
+	next := fn
+	p := interceptors.Load()
+	for i := len(*p) - 1; i >= 0; i-- {
+		ic, inner := (*p)[i], next
+		next = func(c *Call) (any, bool) {
+			return ic.Intercept(c, inner)
+		}
+	}
+	return next(c)
+}
@@ -0,0 +1,81 @@
+package cachestore
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// internMaxStrings caps the intern table, later tags are not interned until tags are dropped
+const internMaxStrings = 1 << 16
+
+var (
+	internEnabled uint32
+	interned      sync.Map // string => string
+	internSaved   atomic.Int64
+	internCount   atomic.Int64
+	internSkipped atomic.Int64
+	internDropped atomic.Int64
+)
+
+// SetInternTags enables sharing one copy of each tag string between entries,
+// a tag is dropped from the table by DeleteTag and every tag by Clear.
+func SetInternTags(value bool) {
+	if value {
+		atomic.StoreUint32(&internEnabled, 1)
+	} else {
+		atomic.StoreUint32(&internEnabled, 0)
+	}
+}
+
+func internTag(tag string) string {
+	if tag == "" || atomic.LoadUint32(&internEnabled) == 0 {
+		return tag
+	}
+	if v, ok := interned.Load(tag); ok {
+		internSaved.Add(int64(len(tag)))
+		return v.(string)
+	}
+	if internCount.Load() >= internMaxStrings {
+		internSkipped.Add(1)
+		return tag
+	}
+	v, loaded := interned.LoadOrStore(tag, tag)
+	if loaded {
+		internSaved.Add(int64(len(tag)))
+	} else {
+		internCount.Add(1)
+	}
+	return v.(string)
+}
+
+// dropInterned removes tag from the intern table, entries keep sharing the dropped copy
+func dropInterned(tag string) {
+	if _, ok := interned.LoadAndDelete(tag); ok {
+		internCount.Add(-1)
+		internDropped.Add(1)
+	}
+}
+
+// dropAllInterned empties the intern table
+func dropAllInterned() {
+	interned.Range(func(k, _ any) bool {
+		dropInterned(k.(string))
+		return true
+	})
+}
+
+type InternStats struct {
+	Strings    int64 // number of interned tags
+	BytesSaved int64 // bytes not allocated by reusing interned tags
+	Skipped    int64 // tags not interned because the table was full
+	Dropped    int64 // tags removed from the table by DeleteTag and Clear
+}
+
+func InternStatus() InternStats {
+	return InternStats{
+		Strings:    internCount.Load(),
+		BytesSaved: internSaved.Load(),
+		Skipped:    internSkipped.Load(),
+		Dropped:    internDropped.Load(),
+	}
+}
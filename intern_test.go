@@ -0,0 +1,30 @@
+package cachestore
+
+import "testing"
+
+func TestInternDroppedByDeleteTagAndClear(t *testing.T) {
+	SetInternTags(true)
+	defer SetInternTags(false)
+	defer Clear()
+
+	Set("intern-a", 1, WithTag("intern-tag-a"))
+	Set("intern-b", 2, WithTag("intern-tag-b"))
+	Set("intern-c", 3, WithTag("intern-tag-b"))
+	if _, ok := interned.Load("intern-tag-a"); !ok {
+		t.Fatal("tag not interned")
+	}
+
+	before := InternStatus()
+	DeleteTag("intern-tag-a")
+	if _, ok := interned.Load("intern-tag-a"); ok {
+		t.Fatal("tag still interned after DeleteTag")
+	}
+	if s := InternStatus(); s.Dropped != before.Dropped+1 || s.Strings != before.Strings-1 {
+		t.Fatalf("stats after DeleteTag = %+v, before %+v", s, before)
+	}
+
+	Clear()
+	if s := InternStatus(); s.Strings != 0 {
+		t.Fatalf("interned strings after Clear = %d", s.Strings)
+	}
+}
@@ -0,0 +1,37 @@
+package cachestore
+
+import "context"
+
+type Invalidation struct {
+	Keys     []string
+	Tags     []string
+	Prefixes []string
+}
+
+// Apply deletes all keys, tags and prefixes in inv
+func (inv Invalidation) Apply() {
+	for _, k := range inv.Keys {
+		Delete(k)
+	}
+	for _, t := range inv.Tags {
+		DeleteTag(t)
+	}
+	for _, p := range inv.Prefixes {
+		DeletePrefix(p)
+	}
+}
+
+// ConsumeInvalidations reads messages from next and applies the invalidation extract returns for each one,
+// until next returns an error, which is returned.
+//
+// next matches reader functions of most brokers,
+// for example kafka.Reader.ReadMessage from segmentio/kafka-go.
+func ConsumeInvalidations[M any](ctx context.Context, next func(ctx context.Context) (M, error), extract func(m M) Invalidation) error {
+	for {
+		m, err := next(ctx)
+		if err != nil {
+			return err
+		}
+		extract(m).Apply()
+	}
+}
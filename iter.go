@@ -0,0 +1,27 @@
+//go:build go1.23
+
+package cachestore
+
+import "iter"
+
+// All returns live entries which value is a T, in no particular order, see RangeAll
+func All[T any]() iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		RangeAll(yield)
+	}
+}
+
+// ByTag returns live entries with tag which value is a T, in no particular order, see RangeByTag
+func ByTag[T any](tag string) iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		RangeByTag(tag, yield)
+	}
+}
+
+// PartitionKeys returns keys set by SetWarmKeys and cached keys that fall in partition i of n,
+// see RangePartitionKeys.
+func PartitionKeys(n, i int) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		RangePartitionKeys(n, i, yield)
+	}
+}
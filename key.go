@@ -0,0 +1,58 @@
+package cachestore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync/atomic"
+)
+
+type keyNormalizer struct {
+	fn func(string) string
+}
+
+var normalizer atomic.Pointer[keyNormalizer]
+
+// SetKeyNormalizer sets fn to be applied to keys on every operation, nil removes the normalizer.
+//
+// fn must be idempotent, a key may be normalized more than once.
+func SetKeyNormalizer(fn func(string) string) {
+	if fn == nil {
+		normalizer.Store(nil)
+		return
+	}
+	normalizer.Store(&keyNormalizer{fn: fn})
+}
+
+func normalizeKey(key string) string {
+	n := normalizer.Load()
+	if n == nil {
+		return key
+	}
+	return n.fn(key)
+}
+
+const hashedKeyLen = 1 + sha256.Size*2
+
+// HashLongKeys returns a key normalizer that replaces keys longer than maxLen
+// with their first prefixLen bytes followed by a sha256 of the whole key.
+//
+// maxLen is raised to fit the hash, and prefixLen is cut to fit maxLen,
+// so hashed keys are never hashed again.
+func HashLongKeys(maxLen, prefixLen int) func(string) string {
+	if maxLen < hashedKeyLen {
+		maxLen = hashedKeyLen
+	}
+	if prefixLen > maxLen-hashedKeyLen {
+		prefixLen = maxLen - hashedKeyLen
+	}
+	if prefixLen < 0 {
+		prefixLen = 0
+	}
+	return func(key string) string {
+		if len(key) <= maxLen {
+			return key
+		}
+		h := sha256.Sum256([]byte(key))
+		return key[:prefixLen] + "#" + hex.EncodeToString(h[:])
+	}
+}
@@ -0,0 +1,157 @@
+package cachestore
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Key is a key made of parts, entries set with SetKey can be invalidated by any prefix of parts
+type Key struct {
+	Parts []string
+}
+
+func NewKey(parts ...string) Key {
+	return Key{Parts: parts}
+}
+
+// String returns the key used in the store, parts are escaped and joined with /
+func (k Key) String() string {
+	xs := make([]string, len(k.Parts))
+	for i, p := range k.Parts {
+		xs[i] = url.PathEscape(p)
+	}
+	return strings.Join(xs, "/")
+}
+
+type keyNode struct {
+	children map[string]*keyNode
+	leaf     bool
+}
+
+var (
+	keyTrieMu sync.Mutex
+	keyTrie   = &keyNode{}
+)
+
+// SetKey sets value for k and indexes k for Invalidate,
+// the index entry is removed when the entry is deleted, expires or is dropped by GC.
+func SetKey(k Key, value any, opts ...Option) {
+	parts := append([]string(nil), k.Parts...)
+	opts = append(opts[:len(opts):len(opts)], optionFunc(func(o *SetOptions) {
+		o.keyParts = parts
+	}))
+	Set(k.String(), value, opts...)
+
+	keyTrieMu.Lock()
+	defer keyTrieMu.Unlock()
+
+	n := keyTrie
+	for _, p := range parts {
+		c := n.children[p]
+		if c == nil {
+			if n.children == nil {
+				n.children = map[string]*keyNode{}
+			}
+			c = &keyNode{}
+			n.children[p] = c
+		}
+		n = c
+	}
+	n.leaf = true
+}
+
+func GetKey[T any](k Key) (T, bool) {
+	return Get[T](k.String())
+}
+
+// Invalidate deletes every entry set with SetKey which key starts with parts
+// and returns the number of deleted entries, it only visits keys under parts.
+func Invalidate(parts ...string) int {
+	var keys []string
+
+	keyTrieMu.Lock()
+	parent, n := (*keyNode)(nil), keyTrie
+	for _, p := range parts {
+		parent, n = n, n.children[p]
+		if n == nil {
+			keyTrieMu.Unlock()
+			return 0
+		}
+	}
+
+	var walk func(n *keyNode, parts []string)
+	walk = func(n *keyNode, parts []string) {
+		if n.leaf {
+			keys = append(keys, Key{Parts: parts}.String())
+		}
+		for p, c := range n.children {
+			walk(c, append(parts[:len(parts):len(parts)], p))
+		}
+	}
+	walk(n, parts)
+
+	if parent == nil {
+		keyTrie = &keyNode{}
+	} else {
+		delete(parent.children, parts[len(parts)-1])
+	}
+	keyTrieMu.Unlock()
+
+	// deleting runs hooks, which may call SetKey
+	cnt := 0
+	for _, k := range keys {
+		if deleteItem(k) {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+// unindexKey removes the index entry of parts after the entry of key was removed,
+// unless key holds a newer entry set with SetKey.
+func unindexKey(key string, parts []string) {
+	keyTrieMu.Lock()
+	defer keyTrieMu.Unlock()
+
+	if _, it, ok := loadItemMap(key); ok && it.keyParts != nil {
+		return
+	}
+
+	path := make([]*keyNode, 0, len(parts)+1)
+	n := keyTrie
+	path = append(path, n)
+	for _, p := range parts {
+		if n = n.children[p]; n == nil {
+			return
+		}
+		path = append(path, n)
+	}
+	n.leaf = false
+	for i := len(parts); i > 0 && !path[i].leaf && len(path[i].children) == 0; i-- {
+		delete(path[i-1].children, parts[i-1])
+	}
+}
+
+// pruneKeyTrie removes index entries of keys no longer in the store,
+// entries dropped with a generation are removed without a delete.
+func pruneKeyTrie() {
+	keyTrieMu.Lock()
+	defer keyTrieMu.Unlock()
+
+	var walk func(n *keyNode, parts []string) bool
+	walk = func(n *keyNode, parts []string) bool {
+		if n.leaf {
+			if it, ok := loadItem(Key{Parts: parts}.String()); !ok || it.keyParts == nil {
+				n.leaf = false
+			}
+		}
+		for p, c := range n.children {
+			if walk(c, append(parts[:len(parts):len(parts)], p)) {
+				delete(n.children, p)
+			}
+		}
+		return !n.leaf && len(n.children) == 0
+	}
+	walk(keyTrie, nil)
+}
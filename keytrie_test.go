@@ -0,0 +1,75 @@
+package cachestore
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func keyTrieSize() int {
+	keyTrieMu.Lock()
+	defer keyTrieMu.Unlock()
+
+	var count func(n *keyNode) int
+	count = func(n *keyNode) int {
+		c := 0
+		for _, x := range n.children {
+			c += 1 + count(x)
+		}
+		return c
+	}
+	return count(keyTrie)
+}
+
+func TestKeyTriePrunedOnDelete(t *testing.T) {
+	base := keyTrieSize()
+	for i := 0; i < 100; i++ {
+		k := NewKey(t.Name(), strconv.Itoa(i))
+		SetKey(k, i)
+		Delete(k.String())
+	}
+	SetKey(NewKey(t.Name(), "expiring"), 0, WithTTL(time.Millisecond))
+	time.Sleep(2 * time.Millisecond)
+	GC()
+
+	if n := keyTrieSize(); n != base {
+		t.Fatalf("index holds %d nodes after deletes, want %d", n, base)
+	}
+}
+
+func TestKeyTriePrunedAfterRotate(t *testing.T) {
+	base := keyTrieSize()
+	SetKey(NewKey(t.Name(), "a"), 1)
+	Rotate(0)
+	GC()
+
+	if n := keyTrieSize(); n != base {
+		t.Fatalf("index holds %d nodes after rotate, want %d", n, base)
+	}
+}
+
+func TestKeyTrieInterceptorCallsInvalidate(t *testing.T) {
+	k := NewKey(t.Name(), "a")
+	Use(InterceptorFunc(func(c *Call, next Invoker) (any, bool) {
+		if c.Op == OpSet && c.Key == k.String() {
+			Invalidate(t.Name(), "other")
+		}
+		return next(c)
+	}))
+	t.Cleanup(func() { interceptors.Store(nil) })
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		SetKey(k, 1)
+		Invalidate(t.Name())
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("SetKey deadlocked with an interceptor calling Invalidate")
+	}
+	if _, ok := GetKey[int](k); ok {
+		t.Fatal("entry not invalidated")
+	}
+}
@@ -0,0 +1,125 @@
+package cachestore
+
+import (
+	"path"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const latencySampleSize = 256
+
+type LatencyStats struct {
+	Count uint64 // loads since tracking started
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+type loadLatency struct {
+	pattern string
+
+	mu      sync.Mutex
+	count   uint64
+	samples [latencySampleSize]time.Duration
+}
+
+func (l *loadLatency) observe(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.samples[l.count%latencySampleSize] = d
+	l.count++
+}
+
+// stats returns percentiles of the last latencySampleSize loads
+func (l *loadLatency) stats() LatencyStats {
+	l.mu.Lock()
+	n := l.count
+	if n > latencySampleSize {
+		n = latencySampleSize
+	}
+	xs := append([]time.Duration(nil), l.samples[:n]...)
+	st := LatencyStats{Count: l.count}
+	l.mu.Unlock()
+
+	if len(xs) == 0 {
+		return st
+	}
+	sort.Slice(xs, func(i, j int) bool { return xs[i] < xs[j] })
+	at := func(p int) time.Duration {
+		return xs[(len(xs)-1)*p/100]
+	}
+	st.P50, st.P90, st.P99 = at(50), at(90), at(99)
+	st.Max = xs[len(xs)-1]
+	return st
+}
+
+var (
+	loadLatenciesMu sync.Mutex
+	loadLatencies   atomic.Pointer[[]*loadLatency]
+)
+
+// TrackLoadLatency tracks how long GetOrSet loaders take for keys matching pattern in path.Match syntax
+func TrackLoadLatency(pattern string) {
+	loadLatenciesMu.Lock()
+	defer loadLatenciesMu.Unlock()
+
+	var ls []*loadLatency
+	if p := loadLatencies.Load(); p != nil {
+		ls = append(ls, *p...)
+	}
+	for _, l := range ls {
+		if l.pattern == pattern {
+			return
+		}
+	}
+	ls = append(ls, &loadLatency{pattern: pattern})
+	loadLatencies.Store(&ls)
+}
+
+// LoadLatencies returns loader latency of each tracked pattern
+func LoadLatencies() map[string]LatencyStats {
+	m := map[string]LatencyStats{}
+	if p := loadLatencies.Load(); p != nil {
+		for _, l := range *p {
+			m[l.pattern] = l.stats()
+		}
+	}
+	return m
+}
+
+type slowLoaderHook struct {
+	threshold time.Duration
+	fn        func(key string, d time.Duration)
+}
+
+var slowLoader atomic.Pointer[slowLoaderHook]
+
+// SetSlowLoader calls fn after a GetOrSet loader took longer than threshold,
+// nil fn removes the hook.
+func SetSlowLoader(threshold time.Duration, fn func(key string, d time.Duration)) {
+	if fn == nil {
+		slowLoader.Store(nil)
+		return
+	}
+	slowLoader.Store(&slowLoaderHook{threshold: threshold, fn: fn})
+}
+
+func observeLoad(key string, d time.Duration) {
+	if h := slowLoader.Load(); h != nil && d > h.threshold {
+		h.fn(key, d)
+	}
+
+	p := loadLatencies.Load()
+	if p == nil {
+		return
+	}
+	for _, l := range *p {
+		if ok, _ := path.Match(l.pattern, key); ok {
+			l.observe(d)
+		}
+	}
+}
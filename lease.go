@@ -0,0 +1,103 @@
+package cachestore
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// leases holds leases by normalized key, apart from cache entries
+var (
+	leases     sync.Map // string => *leaseEntry
+	leaseToken atomic.Uint64
+)
+
+type leaseEntry struct {
+	token     uint64
+	expiresAt time.Time
+}
+
+func (e *leaseEntry) expired(now time.Time) bool {
+	return !now.Before(e.expiresAt)
+}
+
+// Lease is held on a key until it expires or is released
+type Lease struct {
+	key   string
+	token uint64 // unique to the lease, zero for no lease
+}
+
+// TryLease takes a lease on key for ttl unless another live lease holds it, in this process.
+//
+// Leases are kept apart from cache entries, Set, Get, Delete, DeleteTag and Clear do not affect them,
+// GC removes expired leases.
+func TryLease(key string, ttl time.Duration) (Lease, bool) {
+	if ttl <= 0 || isDisabled() {
+		return Lease{}, false
+	}
+
+	key = normalizeKey(key)
+	l := Lease{key: key, token: leaseToken.Add(1)}
+	for {
+		now := time.Now()
+		e := &leaseEntry{token: l.token, expiresAt: now.Add(ttl)}
+		v, loaded := leases.LoadOrStore(key, e)
+		if !loaded {
+			return l, true
+		}
+		if !v.(*leaseEntry).expired(now) {
+			return Lease{}, false
+		}
+		if leases.CompareAndSwap(key, v, e) {
+			return l, true
+		}
+	}
+}
+
+// held returns the entry of l while l is live
+func (l Lease) held() (*leaseEntry, bool) {
+	if l.token == 0 {
+		return nil, false
+	}
+	v, ok := leases.Load(l.key)
+	if !ok {
+		return nil, false
+	}
+	e := v.(*leaseEntry)
+	if e.token != l.token || e.expired(time.Now()) {
+		return nil, false
+	}
+	return e, true
+}
+
+// Renew extends the lease to ttl from now and reports whether it was still held
+func (l Lease) Renew(ttl time.Duration) bool {
+	for {
+		e, ok := l.held()
+		if !ok {
+			return false
+		}
+		if leases.CompareAndSwap(l.key, e, &leaseEntry{token: l.token, expiresAt: time.Now().Add(ttl)}) {
+			return true
+		}
+	}
+}
+
+// Release gives up the lease and reports whether it was still held
+func (l Lease) Release() bool {
+	e, ok := l.held()
+	if !ok {
+		return false
+	}
+	return leases.CompareAndDelete(l.key, e)
+}
+
+// pruneLeases removes leases expired by now
+func pruneLeases(now time.Time) {
+	leases.Range(func(key, v any) bool {
+		if v.(*leaseEntry).expired(now) {
+			leases.CompareAndDelete(key, v)
+		}
+		return true
+	})
+}
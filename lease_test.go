@@ -0,0 +1,65 @@
+package cachestore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeaseStaleHolder(t *testing.T) {
+	key := t.Name()
+
+	a, ok := TryLease(key, 10*time.Millisecond)
+	if !ok {
+		t.Fatal("first lease not taken")
+	}
+	time.Sleep(20 * time.Millisecond)
+	b, ok := TryLease(key, time.Minute)
+	if !ok {
+		t.Fatal("lease not taken after the first expired")
+	}
+	defer b.Release()
+
+	if a.Renew(time.Minute) {
+		t.Fatal("expired lease renewed over the new holder")
+	}
+	if a.Release() {
+		t.Fatal("expired lease released the new holder")
+	}
+	if _, ok := TryLease(key, time.Minute); ok {
+		t.Fatal("lease taken while the new holder holds it")
+	}
+	if !b.Renew(time.Minute) {
+		t.Fatal("new holder lost its lease")
+	}
+}
+
+func TestLeaseApartFromEntries(t *testing.T) {
+	key := t.Name()
+	t.Cleanup(func() { Delete(key) })
+
+	Set(key, "value")
+	l, ok := TryLease(key, time.Minute)
+	if !ok {
+		t.Fatal("lease not taken")
+	}
+	defer l.Release()
+	if v, ok := Get[string](key); !ok || v != "value" {
+		t.Fatalf("entry: got %q, %v", v, ok)
+	}
+
+	Clear()
+	if _, ok := TryLease(key, time.Minute); ok {
+		t.Fatal("lease taken after Clear while held")
+	}
+	if !l.Renew(time.Minute) {
+		t.Fatal("renew failed after Clear")
+	}
+	if !l.Release() {
+		t.Fatal("release failed")
+	}
+	l, ok = TryLease(key, time.Minute)
+	if !ok {
+		t.Fatal("lease not taken after release")
+	}
+	l.Release()
+}
@@ -0,0 +1,366 @@
+package cachestore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type flight struct {
+	done     chan struct{}
+	val      any
+	err      error
+	panic    any // recovered from fn, re-panicked in waiters
+	duration time.Duration
+	version  uint64 // version of the value stored by the flight
+
+	cancel  context.CancelFunc // cancels the context given to fn
+	waiters int                // callers waiting for the flight, guarded by flightsMu
+}
+
+var (
+	flightsMu sync.Mutex
+	flights   = map[string]*flight{}
+	inFlight  atomic.Int64
+)
+
+// InFlight returns the number of running loaders
+func InFlight() int {
+	return int(inFlight.Load())
+}
+
+const idlePollInterval = 10 * time.Millisecond
+
+// WaitIdle waits until no loader is running
+func WaitIdle(ctx context.Context) error {
+	if InFlight() == 0 {
+		return nil
+	}
+	t := time.NewTicker(idlePollInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			if InFlight() == 0 {
+				return nil
+			}
+		}
+	}
+}
+
+// startFlight runs fn in a new goroutine unless a flight for key is already running,
+// in which case the caller joins the running flight.
+// When the goroutine cap is reached fn runs in the calling goroutine.
+//
+// fn is given the version to store its value with, and a context that keeps the values of ctx
+// but is canceled only when every caller gave up waiting, see wait.
+func startFlight(ctx context.Context, key string, fn func(ctx context.Context, version uint64) (any, error)) *flight {
+	key = normalizeKey(key)
+
+	flightsMu.Lock()
+	if f, ok := flights[key]; ok && f.waiters > 0 {
+		f.waiters++
+		flightsMu.Unlock()
+		return f
+	}
+	fctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	f := &flight{done: make(chan struct{}), version: nextVersion(), cancel: cancel, waiters: 1}
+	flights[key] = f
+	inFlight.Add(1)
+	flightsMu.Unlock()
+
+	run := func() {
+		start := time.Now()
+		defer func() {
+			if r := recover(); r != nil {
+				f.val, f.panic = nil, r
+				f.err = fmt.Errorf("cachestore: loader panic: %v", r)
+			}
+			f.duration = time.Since(start)
+
+			flightsMu.Lock()
+			if flights[key] == f {
+				delete(flights, key)
+			}
+			inFlight.Add(-1)
+			flightsMu.Unlock()
+			cancel()
+			close(f.done)
+		}()
+		f.val, f.err = fn(fctx, f.version)
+	}
+	if !spawn(run) {
+		run()
+	}
+	return f
+}
+
+// wait waits for f until ctx is done, the last caller to leave before f is done cancels it
+func (f *flight) wait(ctx context.Context) error {
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+	}
+
+	flightsMu.Lock()
+	f.waiters--
+	last := f.waiters == 0
+	flightsMu.Unlock()
+
+	select {
+	case <-f.done:
+		return nil
+	default:
+	}
+	if last {
+		f.cancel()
+	}
+	return ctx.Err()
+}
+
+type Source int
+
+const (
+	Hit    Source = iota // value was cached
+	Loaded               // value was returned by loader
+	Stale                // stale value was served because loader did not finish in time
+)
+
+func (s Source) String() string {
+	switch s {
+	case Hit:
+		return "HIT"
+	case Loaded:
+		return "MISS"
+	case Stale:
+		return "STALE"
+	}
+	return "UNKNOWN"
+}
+
+// Result is a value returned from GetOrSetResult with how it was obtained
+type Result[T any] struct {
+	Value        T
+	Source       Source
+	Age          time.Duration // age of the cached value, zero when loaded
+	LoadDuration time.Duration // time taken by loader, zero when cached
+}
+
+// callLoader calls loader without the cache
+func callLoader[T any](ctx context.Context, loader func(ctx context.Context) (T, error)) (Result[T], error) {
+	if err := checkDeadline(ctx); err != nil {
+		return Result[T]{}, err
+	}
+	start := time.Now()
+	v, err := loader(ctx)
+	if err != nil {
+		return Result[T]{}, err
+	}
+	return Result[T]{Value: v, Source: Loaded, LoadDuration: time.Since(start)}, nil
+}
+
+func load[T any](ctx context.Context, key string, loader func(ctx context.Context) (T, error), opt *SetOptions) (Result[T], error) {
+	if isDisabled() {
+		return callLoader(ctx, loader)
+	}
+	if err := checkDeadline(ctx); err != nil {
+		return Result[T]{}, err
+	}
+	// loaders run in another goroutine, capture the call site here
+	if pcs := captureCallers(); pcs != nil {
+		o := SetOptions{}
+		if opt != nil {
+			o = *opt
+		}
+		o.callers = pcs
+		opt = &o
+	}
+
+	f := startFlight(ctx, key, func(ctx context.Context, version uint64) (any, error) {
+		release, err := coldStartSlot(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		start := time.Now()
+		v, err := loadBatched(ctx, key, loader)
+		d := time.Since(start)
+		observeLoad(key, d)
+		if err != nil {
+			if opt != nil && opt.ErrorTTL > 0 {
+				setErr(key, err, opt)
+			}
+			return nil, err
+		}
+		setVersion(key, v, opt, d, version)
+		return v, nil
+	})
+
+	if err := f.wait(ctx); err != nil {
+		return Result[T]{}, err
+	}
+	if f.panic != nil {
+		panic(f.panic)
+	}
+	// a value stored by another path while loading wins over the flight result
+	if it, ok := loadItem(key); ok && it.NewerThan(f.version) && !it.Expired() {
+		if r, ok, err := itemResult[T](key, it, Hit); ok {
+			return r, err
+		}
+	}
+	if f.err != nil {
+		return Result[T]{}, f.err
+	}
+	v, _ := f.val.(T)
+	return Result[T]{Value: v, Source: Loaded, LoadDuration: f.duration}, nil
+}
+
+// getFresh is Get that may report a miss before expiry, see SetEarlyExpiration,
+// a cached error is returned as a hit with the error.
+func getFresh[T any](key string) (Result[T], bool, error) {
+	if isDisabled() {
+		return Result[T]{}, false, nil
+	}
+
+	it, ok := loadItem(key)
+	if !ok || it.Expired() || it.expireEarly() {
+		return Result[T]{}, false, nil
+	}
+	r := Result[T]{Source: Hit, Age: time.Since(it.createdAt)}
+	if it.err != nil {
+		return r, true, it.err
+	}
+	it.checkImmutable(key)
+	data, err := loadTransform(it.data)
+	if err != nil {
+		return Result[T]{}, false, nil
+	}
+	v, match := data.(T)
+	if !match && data != nil {
+		v, ok, err := resolveMismatch[T](key, it, data)
+		if !ok {
+			return Result[T]{}, false, nil
+		}
+		r.Value = v
+		return r, true, err
+	}
+	it.recordAccess()
+	it.extend(key)
+	r.Value = v
+	return r, true, nil
+}
+
+// itemResult returns the value of it for GetOrSet,
+// a value of another type goes through resolveMismatch, see SetMismatchPolicy.
+func itemResult[T any](key string, it *item, source Source) (Result[T], bool, error) {
+	if it.err != nil {
+		return Result[T]{}, false, nil
+	}
+	data, err := loadTransform(it.data)
+	if err != nil {
+		return Result[T]{}, false, nil
+	}
+	r := Result[T]{Source: source, Age: time.Since(it.createdAt)}
+	v, match := data.(T)
+	if !match && data != nil {
+		v, ok, err := resolveMismatch[T](key, it, data)
+		if !ok {
+			return Result[T]{}, false, nil
+		}
+		r.Value = v
+		return r, true, err
+	}
+	it.recordAccess()
+	r.Value = v
+	return r, true, nil
+}
+
+// GetOrSet returns the cached value for key,
+// or calls loader and stores its result when key is missing.
+//
+// Concurrent calls for the same key share a single loader call,
+// ctx bounds only the wait of its caller, the loader is canceled when every waiting call gave up.
+// A loader error is returned to every waiting call and is never stored unless WithErrorTTL is given,
+// the key keeps its previous entry or stays absent. A loader panic is re-panicked in every waiting call.
+func GetOrSet[T any](ctx context.Context, key string, loader func(ctx context.Context) (T, error), opts ...Option) (T, error) {
+	r, err := GetOrSetResult(ctx, key, loader, opts...)
+	return r.Value, err
+}
+
+// GetOrSetResult is GetOrSet that also reports how the value was obtained
+func GetOrSetResult[T any](ctx context.Context, key string, loader func(ctx context.Context) (T, error), opts ...Option) (Result[T], error) {
+	if bypassCache(key) {
+		return callLoader(ctx, loader)
+	}
+
+	opt := resolveOptions(opts)
+	key = contextKey(ctx, key, opt)
+	r, ok, err := getFresh[T](key)
+	audit(OpGetOrSet, key, ok, opt)
+	trackHit(key, ok)
+	if ok {
+		if r, ok := serveStale[T](key, err, opt); ok { // err is cached, see WithErrorTTL
+			return r, nil
+		}
+		return r, err
+	}
+	r, err = load(ctx, key, loader, opt)
+	if opt != nil && opt.MaxServeStale > 0 {
+		if r, ok := serveStale[T](key, err, opt); ok {
+			return r, nil
+		}
+	}
+	return r, err
+}
+
+// GetOrSetWithTimeout is GetOrSet that abandons loader after timeout.
+//
+// The context passed to loader is canceled after timeout unless another call still waits for it,
+// if loader does not return in time, or is not called because of SetMinLoadTime,
+// the stale value for key is returned when exists, see WithMaxServeStale,
+// otherwise context.DeadlineExceeded or ErrDeadlineTooShort is returned.
+func GetOrSetWithTimeout[T any](ctx context.Context, key string, timeout time.Duration, loader func(ctx context.Context) (T, error), opts ...Option) (T, error) {
+	r, err := GetOrSetWithTimeoutResult(ctx, key, timeout, loader, opts...)
+	return r.Value, err
+}
+
+// GetOrSetWithTimeoutResult is GetOrSetWithTimeout that also reports how the value was obtained
+func GetOrSetWithTimeoutResult[T any](ctx context.Context, key string, timeout time.Duration, loader func(ctx context.Context) (T, error), opts ...Option) (Result[T], error) {
+	if bypassCache(key) {
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return callLoader(ctx, loader)
+	}
+
+	opt := resolveOptions(opts)
+	key = contextKey(ctx, key, opt)
+	r, ok, err := getFresh[T](key)
+	audit(OpGetOrSet, key, ok, opt)
+	trackHit(key, ok)
+	if ok {
+		if r, ok := serveStale[T](key, err, opt); ok { // err is cached, see WithErrorTTL
+			return r, nil
+		}
+		return r, err
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	r, err = load(ctx, key, loader, opt)
+	if r, ok := serveStale[T](key, err, opt); ok {
+		return r, nil
+	}
+	return r, err
+}
@@ -0,0 +1,200 @@
+package cachestore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetOrSetFirstCallerCancel(t *testing.T) {
+	key := t.Name()
+	t.Cleanup(func() { Delete(key) })
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (string, error) {
+		close(started)
+		select {
+		case <-release:
+			return "value", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := GetOrSet(ctx, key, loader)
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan string, 1)
+	secondErr := make(chan error, 1)
+	go func() {
+		v, err := GetOrSet(context.Background(), key, func(context.Context) (string, error) {
+			t.Error("second loader called, want shared flight")
+			return "", nil
+		})
+		second <- v
+		secondErr <- err
+	}()
+	waitWaiters(t, key, 2)
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first caller: got %v, want context.Canceled", err)
+	}
+	close(release)
+
+	if err := <-secondErr; err != nil {
+		t.Fatalf("second caller: got %v, want value", err)
+	}
+	if v := <-second; v != "value" {
+		t.Fatalf("second caller: got %q, want %q", v, "value")
+	}
+	if v, ok := Get[string](key); !ok || v != "value" {
+		t.Fatalf("cached: got %q, %v", v, ok)
+	}
+}
+
+func TestGetOrSetLastCallerCancel(t *testing.T) {
+	key := t.Name()
+	t.Cleanup(func() { Delete(key) })
+
+	canceled := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for InFlight() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+	_, err := GetOrSet(ctx, key, func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		close(canceled)
+		return "", ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("loader not canceled after its only caller left")
+	}
+}
+
+// waitWaiters waits until n callers wait for the flight of key
+func waitWaiters(t *testing.T, key string, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		flightsMu.Lock()
+		f := flights[normalizeKey(key)]
+		ok := f != nil && f.waiters >= n
+		flightsMu.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("%d callers not waiting for %q", n, key)
+}
+
+func TestGetOrSetErrorNotStored(t *testing.T) {
+	key := t.Name()
+	t.Cleanup(func() { Delete(key) })
+
+	errLoad := errors.New("load failed")
+	_, err := GetOrSet(context.Background(), key, func(context.Context) (string, error) {
+		return "partial", errLoad
+	})
+	if !errors.Is(err, errLoad) {
+		t.Fatalf("got %v, want %v", err, errLoad)
+	}
+	if _, ok := loadItem(key); ok {
+		t.Fatal("failed load left an entry")
+	}
+
+	_, err = GetOrSet(context.Background(), key, func(context.Context) (string, error) {
+		return "", errLoad
+	}, WithErrorTTL(time.Minute))
+	if !errors.Is(err, errLoad) {
+		t.Fatalf("got %v, want %v", err, errLoad)
+	}
+	_, err = GetOrSet(context.Background(), key, func(context.Context) (string, error) {
+		t.Error("loader called, want cached error")
+		return "", nil
+	}, WithErrorTTL(time.Minute))
+	if !errors.Is(err, errLoad) {
+		t.Fatalf("cached: got %v, want %v", err, errLoad)
+	}
+}
+
+// TestGetOrSetFailureInterleavings mixes failing and succeeding loaders for one key,
+// a failing loader must never replace a stored value or leave one behind.
+func TestGetOrSetFailureInterleavings(t *testing.T) {
+	key := t.Name()
+	t.Cleanup(func() { Delete(key) })
+
+	errLoad := errors.New("load failed")
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				fail := (w+i)%2 == 0
+				v, err := GetOrSet(context.Background(), key, func(context.Context) (int, error) {
+					if fail {
+						return -1, errLoad
+					}
+					return i, nil
+				})
+				if err != nil && !errors.Is(err, errLoad) {
+					t.Errorf("got error %v", err)
+				}
+				if err == nil && v < 0 {
+					t.Errorf("got value %d of a failed load", v)
+				}
+				if it, ok := loadItem(key); ok && (it.err != nil || it.data == -1) {
+					t.Errorf("failed load stored %v, %v", it.data, it.err)
+				}
+				if i%10 == 0 {
+					Delete(key)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+func TestGetOrSetPanicLeavesNoFlight(t *testing.T) {
+	key := t.Name()
+	t.Cleanup(func() { Delete(key) })
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("loader panic not re-panicked")
+			}
+		}()
+		GetOrSet(context.Background(), key, func(context.Context) (string, error) {
+			panic("boom")
+		})
+	}()
+	if _, ok := loadItem(key); ok {
+		t.Fatal("panicking load left an entry")
+	}
+	v, err := GetOrSet(context.Background(), key, func(context.Context) (string, error) {
+		return "value", nil
+	})
+	if err != nil || v != "value" {
+		t.Fatalf("after panic: got %q, %v", v, err)
+	}
+}
@@ -0,0 +1,139 @@
+package cachestore
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+const (
+	memorySampleSize  = 64
+	memorySizeDepth   = 4
+	memoryElemSamples = 16 // elements of a slice or map walked, the rest are extrapolated
+)
+
+// flatTypes caches whether a type references no memory outside its inline size
+var flatTypes sync.Map // reflect.Type => bool
+
+func isFlat(t reflect.Type) bool {
+	if v, ok := flatTypes.Load(t); ok {
+		return v.(bool)
+	}
+	flat := true
+	switch t.Kind() {
+	case reflect.String, reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map,
+		reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		flat = false
+	case reflect.Array:
+		flat = isFlat(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !isFlat(t.Field(i).Type) {
+				flat = false
+				break
+			}
+		}
+	}
+	flatTypes.Store(t, flat)
+	return flat
+}
+
+// MemoryUsage returns approximate memory used by entries in bytes,
+// estimated from the sizes of a sample of entries.
+func MemoryUsage() int64 {
+	var (
+		count   int64
+		sampled int64
+		size    int64
+	)
+	rangeItems(func(_ engine, key string, it *item) bool {
+		count++
+		if sampled < memorySampleSize {
+			sampled++
+			size += int64(len(key)) + int64(unsafe.Sizeof(*it)) + valueSize(it.data)
+		}
+		return true
+	})
+	if sampled == 0 {
+		return 0
+	}
+	return size * count / sampled
+}
+
+func valueSize(v any) int64 {
+	if v == nil {
+		return 0
+	}
+	rv := reflect.ValueOf(v)
+	return int64(rv.Type().Size()) + heapSize(rv, memorySizeDepth)
+}
+
+// heapSize estimates bytes referenced by v outside its own inline size
+func heapSize(v reflect.Value, depth int) int64 {
+	if depth < 0 || isFlat(v.Type()) {
+		return 0
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return int64(v.Len())
+	case reflect.Pointer:
+		if v.IsNil() {
+			return 0
+		}
+		e := v.Elem()
+		return int64(e.Type().Size()) + heapSize(e, depth-1)
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		e := v.Elem()
+		return int64(e.Type().Size()) + heapSize(e, depth-1)
+	case reflect.Slice:
+		if v.IsNil() {
+			return 0
+		}
+		return int64(v.Cap())*int64(v.Type().Elem().Size()) + elemsSize(v, depth-1)
+	case reflect.Array:
+		return elemsSize(v, depth-1)
+	case reflect.Map:
+		n := int64(v.Len()) * int64(v.Type().Key().Size()+v.Type().Elem().Size())
+		if isFlat(v.Type().Key()) && isFlat(v.Type().Elem()) {
+			return n
+		}
+		var sampled, size int64
+		iter := v.MapRange()
+		for sampled < memoryElemSamples && iter.Next() {
+			size += heapSize(iter.Key(), depth-1) + heapSize(iter.Value(), depth-1)
+			sampled++
+		}
+		if sampled > 0 {
+			n += size * int64(v.Len()) / sampled
+		}
+		return n
+	case reflect.Struct:
+		var n int64
+		for i := 0; i < v.NumField(); i++ {
+			n += heapSize(v.Field(i), depth)
+		}
+		return n
+	}
+	return 0
+}
+
+// elemsSize estimates heap bytes of elements of slice or array v from the first elements
+func elemsSize(v reflect.Value, depth int) int64 {
+	l := v.Len()
+	if l == 0 || isFlat(v.Type().Elem()) {
+		return 0
+	}
+	sampled := l
+	if sampled > memoryElemSamples {
+		sampled = memoryElemSamples
+	}
+	var n int64
+	for i := 0; i < sampled; i++ {
+		n += heapSize(v.Index(i), depth)
+	}
+	return n * int64(l) / int64(sampled)
+}
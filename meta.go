@@ -0,0 +1,73 @@
+package cachestore
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+var trackAccess uint32
+
+// SetTrackAccess enables recording last access time and hit count of entries,
+// this costs an atomic write on every read.
+func SetTrackAccess(value bool) {
+	if value {
+		atomic.StoreUint32(&trackAccess, 1)
+	} else {
+		atomic.StoreUint32(&trackAccess, 0)
+	}
+}
+
+func isTrackAccess() bool {
+	return atomic.LoadUint32(&trackAccess) == 1
+}
+
+func (it *item) recordAccess() {
+	if !isTrackAccess() {
+		return
+	}
+	it.hits.Add(1)
+	it.lastAccess.Store(time.Now().UnixNano())
+}
+
+type EntryMeta struct {
+	Tag       string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	Meta      map[string]string // see WithMeta
+	SetBy     string            // function and file:line that set the entry, see SetTrackCallers
+
+	// recorded only when SetTrackAccess is enabled
+	LastAccessedAt time.Time
+	HitCount       uint64
+}
+
+// Meta returns metadata of the entry for key, including expired entries
+func Meta(key string) (EntryMeta, bool) {
+	it, ok := loadItem(key)
+	if !ok {
+		return EntryMeta{}, false
+	}
+	m := it.entryMeta()
+	m.SetBy = callSite(it.callers)
+	return m, true
+}
+
+// entryMeta returns metadata of it without SetBy, which is costly to resolve
+func (it *item) entryMeta() EntryMeta {
+	m := EntryMeta{
+		Tag:       it.tag,
+		CreatedAt: it.createdAt,
+		ExpiresAt: it.expiresAt,
+		HitCount:  it.hits.Load(),
+	}
+	if len(it.meta) > 0 {
+		m.Meta = make(map[string]string, len(it.meta))
+		for k, v := range it.meta {
+			m.Meta[k] = v
+		}
+	}
+	if t := it.lastAccess.Load(); t > 0 {
+		m.LastAccessedAt = time.Unix(0, t)
+	}
+	return m
+}
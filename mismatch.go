@@ -0,0 +1,63 @@
+package cachestore
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MismatchPolicy is what GetOrSet does when the cached value is not of the requested type
+type MismatchPolicy int32
+
+const (
+	MismatchPanic MismatchPolicy = iota // panic like Get
+	MismatchMiss                        // treat as a miss, the loader result overwrites the entry
+	MismatchError                       // return ErrTypeMismatch
+)
+
+var ErrTypeMismatch = errors.New("cachestore: cached value type mismatch")
+
+var (
+	mismatchPolicy atomic.Int32
+	migrations     sync.Map // reflect.Type => func(any) (any, bool)
+)
+
+// SetMismatchPolicy sets what GetOrSet does when no migration converts a mismatched value
+func SetMismatchPolicy(p MismatchPolicy) {
+	mismatchPolicy.Store(int32(p))
+}
+
+// RegisterMigration registers fn to convert cached values of other types to T,
+// GetOrSet stores a converted value in place of the old one.
+// fn returns false when it can not convert the value.
+func RegisterMigration[T any](fn func(old any) (T, bool)) {
+	migrations.Store(reflect.TypeOf((*T)(nil)).Elem(), func(old any) (any, bool) {
+		return fn(old)
+	})
+}
+
+func resolveMismatch[T any](key string, it *item, data any) (T, bool, error) {
+	if fn, ok := migrations.Load(reflect.TypeOf((*T)(nil)).Elem()); ok {
+		if v, ok := fn.(func(any) (any, bool))(data); ok {
+			opt := SetOptions{Tag: it.tag, Meta: it.meta}
+			if !it.expiresAt.IsZero() {
+				opt.TTL = time.Until(it.expiresAt)
+				if opt.TTL <= 0 {
+					opt.TTL = time.Nanosecond
+				}
+			}
+			set(key, v, &opt, it.loadDuration)
+			return v.(T), true, nil
+		}
+	}
+
+	switch MismatchPolicy(mismatchPolicy.Load()) {
+	case MismatchMiss:
+		return *new(T), false, nil
+	case MismatchError:
+		return *new(T), true, ErrTypeMismatch
+	}
+	return data.(T), true, nil // panics
+}
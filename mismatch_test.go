@@ -0,0 +1,38 @@
+package cachestore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMismatchMissOnLoaderAndStalePaths(t *testing.T) {
+	SetMismatchPolicy(MismatchMiss)
+	defer SetMismatchPolicy(MismatchPanic)
+	key := t.Name()
+	defer Delete(key)
+	ctx := context.Background()
+
+	// a value of another type stored while loading, ordered writes keep it over the loaded value
+	SetOrderedWrites(true)
+	defer SetOrderedWrites(false)
+	v, err := GetOrSet(ctx, key, func(context.Context) (int, error) {
+		Set(key, "other")
+		return 1, nil
+	})
+	if err != nil || v != 1 {
+		t.Fatalf("loader path = %v, %v, want the loaded 1", v, err)
+	}
+
+	// a stale value of another type after the loader timed out
+	Set(key, "other", WithTTL(time.Nanosecond))
+	time.Sleep(time.Millisecond)
+	_, err = GetOrSetWithTimeout(ctx, key, 5*time.Millisecond, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("stale path err = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,255 @@
+package cachestore
+
+import (
+	"context"
+	"time"
+)
+
+// Option configures how an entry is stored,
+// *SetOptions is an Option that sets all its non-zero fields.
+type Option interface {
+	apply(o *SetOptions)
+}
+
+type SetOptions struct {
+	Tag string
+	TTL time.Duration // zero means no expiry, negative stores the entry already expired
+
+	// ErrorTTL is the TTL of errors returned by loaders, zero means errors are not cached
+	ErrorTTL time.Duration
+
+	// SchemaVersion is the schema of the value, zero means the current schema, see SetCurrentSchema
+	SchemaVersion int
+
+	// Label is recorded with the operation in the audit log, see SetAuditLog
+	Label string
+
+	// FirstWriteWins keeps an existing entry set less than FirstWriteWins ago instead of replacing it
+	FirstWriteWins time.Duration
+
+	// TTLPolicy returns the TTL of an entry when TTL is zero, with the same meaning of zero and negative as TTL
+	TTLPolicy func(key string, value any) time.Duration
+
+	// KeyContext returns a suffix added to keys by GetOrSet, see WithKeyContext
+	KeyContext func(ctx context.Context) string
+
+	// KeepNewer keeps an existing entry written after this write started, see SetOrderedWrites
+	KeepNewer bool
+
+	// Meta is small user metadata kept with the entry, see Meta and AuditLog
+	Meta map[string]string
+
+	// AlignTTL rounds expiry up to a multiple of AlignTTL since the zero time,
+	// with zero TTL the entry expires at the next boundary
+	AlignTTL time.Duration
+
+	// Admit drops the write when it returns false, see SetAdmissionHook
+	Admit func(key string, value any, opt *SetOptions) bool
+
+	// MaxServeStale serves the stale value for up to MaxServeStale after expiry when GetOrSet fails to load,
+	// zero serves no stale value on loader errors and any stale value on GetOrSetWithTimeout timeouts
+	MaxServeStale time.Duration
+
+	// Coalesce skips the write when the existing entry was set less than Coalesce ago
+	// with an equal value, tag and schema, the existing expiry is kept
+	Coalesce time.Duration
+
+	callers  []uintptr // call site of GetOrSet, see SetTrackCallers
+	keyParts []string  // see SetKey
+}
+
+func (opt *SetOptions) apply(o *SetOptions) {
+	if opt == nil {
+		return
+	}
+	if opt.Tag != "" {
+		o.Tag = opt.Tag
+	}
+	if opt.TTL != 0 {
+		o.TTL = opt.TTL
+	}
+	if opt.ErrorTTL != 0 {
+		o.ErrorTTL = opt.ErrorTTL
+	}
+	if opt.SchemaVersion != 0 {
+		o.SchemaVersion = opt.SchemaVersion
+	}
+	if opt.Label != "" {
+		o.Label = opt.Label
+	}
+	if opt.FirstWriteWins != 0 {
+		o.FirstWriteWins = opt.FirstWriteWins
+	}
+	if opt.TTLPolicy != nil {
+		o.TTLPolicy = opt.TTLPolicy
+	}
+	if opt.KeyContext != nil {
+		o.KeyContext = opt.KeyContext
+	}
+	if opt.KeepNewer {
+		o.KeepNewer = true
+	}
+	if opt.MaxServeStale != 0 {
+		o.MaxServeStale = opt.MaxServeStale
+	}
+	if opt.Admit != nil {
+		o.Admit = opt.Admit
+	}
+	if opt.AlignTTL != 0 {
+		o.AlignTTL = opt.AlignTTL
+	}
+	if opt.Coalesce != 0 {
+		o.Coalesce = opt.Coalesce
+	}
+	for k, v := range opt.Meta {
+		o.setMeta(k, v)
+	}
+}
+
+func (o *SetOptions) setMeta(key, value string) {
+	if o.Meta == nil {
+		o.Meta = map[string]string{}
+	}
+	o.Meta[key] = value
+}
+
+type optionFunc func(o *SetOptions)
+
+func (f optionFunc) apply(o *SetOptions) {
+	f(o)
+}
+
+func WithTag(tag string) Option {
+	return optionFunc(func(o *SetOptions) {
+		o.Tag = tag
+	})
+}
+
+func WithTTL(ttl time.Duration) Option {
+	return optionFunc(func(o *SetOptions) {
+		o.TTL = ttl
+	})
+}
+
+// WithErrorTTL caches errors returned by loaders for ttl
+func WithErrorTTL(ttl time.Duration) Option {
+	return optionFunc(func(o *SetOptions) {
+		o.ErrorTTL = ttl
+	})
+}
+
+func WithSchemaVersion(v int) Option {
+	return optionFunc(func(o *SetOptions) {
+		o.SchemaVersion = v
+	})
+}
+
+// WithLabel sets the label recorded in the audit log
+func WithLabel(label string) Option {
+	return optionFunc(func(o *SetOptions) {
+		o.Label = label
+	})
+}
+
+// WithFirstWriteWins keeps an existing entry set less than window ago instead of replacing it
+func WithFirstWriteWins(window time.Duration) Option {
+	return optionFunc(func(o *SetOptions) {
+		o.FirstWriteWins = window
+	})
+}
+
+// WithCoalesce skips writes of a value equal to the entry set less than window ago,
+// values are compared by a hash then reflect.DeepEqual, see SetOptions.Coalesce
+func WithCoalesce(window time.Duration) Option {
+	return optionFunc(func(o *SetOptions) {
+		o.Coalesce = window
+	})
+}
+
+// WithTTLPolicy computes the TTL from key and value when no TTL is given
+func WithTTLPolicy(policy func(key string, value any) time.Duration) Option {
+	return optionFunc(func(o *SetOptions) {
+		o.TTLPolicy = policy
+	})
+}
+
+// WithKeyContext makes GetOrSet use key#dim where dim is returned by fn from the context,
+// such as a tenant or locale, an empty dim leaves key unchanged
+func WithKeyContext(fn func(ctx context.Context) string) Option {
+	return optionFunc(func(o *SetOptions) {
+		o.KeyContext = fn
+	})
+}
+
+// WithKeepNewer keeps an entry written after this write started instead of replacing it,
+// so a slow GetOrSet loader does not overwrite values set while it runs.
+func WithKeepNewer() Option {
+	return optionFunc(func(o *SetOptions) {
+		o.KeepNewer = true
+	})
+}
+
+// WithAlignTTL rounds expiry up to a wall-clock boundary such as the top of the minute,
+// so entries set on different replicas expire together
+func WithAlignTTL(d time.Duration) Option {
+	return optionFunc(func(o *SetOptions) {
+		o.AlignTTL = d
+	})
+}
+
+// WithAdmissionHook drops the write when fn returns false
+func WithAdmissionHook(fn func(key string, value any, opt *SetOptions) bool) Option {
+	return optionFunc(func(o *SetOptions) {
+		o.Admit = fn
+	})
+}
+
+// WithMaxServeStale makes GetOrSet return the stale value when the loader fails,
+// until the value expired more than d ago
+func WithMaxServeStale(d time.Duration) Option {
+	return optionFunc(func(o *SetOptions) {
+		o.MaxServeStale = d
+	})
+}
+
+// WithMeta adds metadata key with value to the entry
+func WithMeta(key, value string) Option {
+	return optionFunc(func(o *SetOptions) {
+		o.setMeta(key, value)
+	})
+}
+
+// contextKey returns key with the suffix from opt.KeyContext
+func contextKey(ctx context.Context, key string, opt *SetOptions) string {
+	if opt == nil || opt.KeyContext == nil {
+		return key
+	}
+	if dim := opt.KeyContext(ctx); dim != "" {
+		return key + "#" + dim
+	}
+	return key
+}
+
+// resolveOptions merges opts in order, it returns nil when there is no option
+func resolveOptions(opts []Option) *SetOptions {
+	var o *SetOptions
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if o == nil {
+			o = new(SetOptions)
+		}
+		opt.apply(o)
+	}
+	return o
+}
+
+// alignUp returns the first multiple of d since the zero time not before t
+func alignUp(t time.Time, d time.Duration) time.Time {
+	a := t.Truncate(d)
+	if a.Before(t) {
+		a = a.Add(d)
+	}
+	return a
+}
@@ -0,0 +1,20 @@
+package cachestore
+
+import "sync/atomic"
+
+var orderedWrites uint32
+
+// SetOrderedWrites makes writes to the same key apply in the order they started,
+// a write that started before the stored entry was written is dropped.
+// Loader results are ordered by the time the load started.
+func SetOrderedWrites(value bool) {
+	if value {
+		atomic.StoreUint32(&orderedWrites, 1)
+	} else {
+		atomic.StoreUint32(&orderedWrites, 0)
+	}
+}
+
+func isOrderedWrites() bool {
+	return atomic.LoadUint32(&orderedWrites) == 1
+}
@@ -0,0 +1,56 @@
+package cachestore
+
+import "time"
+
+// DeleteTagPaced is DeleteTag that deletes at most perSecond entries per second,
+// it blocks until all entries with tag set before the call are deleted.
+func DeleteTagPaced(tag string, perSecond int) int {
+	if perSecond <= 0 {
+		return DeleteTag(tag)
+	}
+	checkTag(tag)
+
+	type entry struct {
+		m   engine
+		key string
+		it  *item
+	}
+
+	v := currentVersion()
+	var xs []entry
+	rangeItems(func(m engine, key string, it *item) bool {
+		if !it.NewerThan(v) && it.tag == tag {
+			xs = append(xs, entry{m, key, it})
+		}
+		return true
+	})
+
+	// every tick earns perSecond·interval of credit and a delete costs a second of it,
+	// the remainder carries to the next tick so the rate holds when interval does not divide a second
+	interval := (time.Second + time.Duration(perSecond) - 1) / time.Duration(perSecond)
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	earn := time.Duration(perSecond) * interval
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	n := 0
+	credit := earn
+	for _, x := range xs {
+		for credit < time.Second {
+			<-t.C
+			credit += earn
+		}
+		credit -= time.Second
+		it, ok := deleteWhile(x.m, x.key, x.it, func(it *item) bool {
+			return it.version == x.it.version
+		})
+		if ok {
+			removed(x.key, it, EventDelete)
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,24 @@
+package cachestore
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDeleteTagPacedRate(t *testing.T) {
+	const n = 60
+	for i := 0; i < n; i++ {
+		Set("paced-"+strconv.Itoa(i), i, WithTag("paced"))
+	}
+	defer DeleteTag("paced")
+
+	// 150 per second takes about 0.4s, a batch truncated to 1 per 10ms tick takes 0.6s
+	start := time.Now()
+	if got := DeleteTagPaced("paced", 150); got != n {
+		t.Fatalf("deleted %d, want %d", got, n)
+	}
+	if d := time.Since(start); d < 300*time.Millisecond || d > 500*time.Millisecond {
+		t.Fatalf("deleting %d at 150 per second took %v", n, d)
+	}
+}
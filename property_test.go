@@ -0,0 +1,191 @@
+package cachestore
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stamp is a value that knows an upper bound of its expiry,
+// it is written after the call that sets the expiry returns, so zero means not known yet.
+type stamp struct {
+	exp  atomic.Int64  // unix nanoseconds
+	done atomic.Uint64 // harness sequence after the call that set it returned, zero while running
+}
+
+// extend raises the upper bound of the expiry to t
+func (s *stamp) extend(t int64) {
+	for {
+		old := s.exp.Load()
+		if old >= t || s.exp.CompareAndSwap(old, t) {
+			return
+		}
+	}
+}
+
+func (s *stamp) extendTTL(ttl time.Duration) {
+	if ttl == 0 {
+		s.extend(math.MaxInt64)
+		return
+	}
+	s.extend(time.Now().Add(ttl).UnixNano())
+}
+
+// expiredBefore reports whether s surely expired before t
+func (s *stamp) expiredBefore(t time.Time) bool {
+	e := s.exp.Load()
+	return e != 0 && e < t.UnixNano()
+}
+
+type harness struct {
+	t      *testing.T
+	prefix string
+	keys   []string
+	tags   []string
+	seq    atomic.Uint64 // orders the end of writes before the start of deletes
+}
+
+func (h *harness) ttl(r *rand.Rand) time.Duration {
+	if r.Intn(4) == 0 {
+		return 0
+	}
+	return time.Duration(1+r.Intn(3)) * time.Millisecond
+}
+
+// checkDeleted fails when an entry matching fn, not newer than v and
+// set by a call that returned before the delete started at seq, is still stored.
+// A write still running when the delete starts may land either side of it.
+func (h *harness) checkDeleted(op string, seq, v uint64, fn func(key string, it *item) bool) {
+	rangeItems(func(_ engine, key string, it *item) bool {
+		s, _ := it.data.(*stamp)
+		if s == nil || s.done.Load() == 0 || s.done.Load() > seq {
+			return true
+		}
+		if strings.HasPrefix(key, h.prefix) && fn(key, it) && !it.NewerThan(v) {
+			h.t.Errorf("%s: %s of version %d set before it at %d survived it", op, key, it.version, v)
+		}
+		return true
+	})
+}
+
+func (h *harness) step(r *rand.Rand) {
+	key := h.keys[r.Intn(len(h.keys))]
+	tag := h.tags[r.Intn(len(h.tags))]
+
+	switch op := r.Intn(100); {
+	case op < 35:
+		ttl := h.ttl(r)
+		s := &stamp{}
+		Set(key, s, WithTag(tag), WithTTL(ttl))
+		s.extendTTL(ttl)
+		s.done.Store(h.seq.Add(1))
+	case op < 65:
+		start := time.Now()
+		if s, ok := Get[*stamp](key); ok && s.expiredBefore(start) {
+			h.t.Errorf("Get %s returned a value expired before the call", key)
+		}
+	case op < 72:
+		start := time.Now()
+		ttl := time.Duration(1+r.Intn(3)) * time.Millisecond
+		if s, ok := GetAndTouch[*stamp](key, ttl); ok {
+			if s.expiredBefore(start) {
+				h.t.Errorf("GetAndTouch %s returned a value expired before the call", key)
+			}
+			s.extendTTL(ttl)
+		}
+	case op < 80:
+		start := time.Now()
+		ttl := h.ttl(r)
+		var loaded *stamp
+		s, err := GetOrSet(context.Background(), key, func(context.Context) (*stamp, error) {
+			loaded = &stamp{}
+			return loaded, nil
+		}, WithTag(tag), WithTTL(ttl))
+		if err != nil {
+			h.t.Errorf("GetOrSet %s: %v", key, err)
+		} else if s != loaded && s.expiredBefore(start) {
+			h.t.Errorf("GetOrSet %s returned a value expired before the call", key)
+		}
+		if loaded != nil {
+			loaded.extendTTL(ttl)
+			loaded.done.Store(h.seq.Add(1))
+		}
+	case op < 88:
+		Delete(key)
+	case op < 95:
+		seq, v := h.seq.Add(1), currentVersion()
+		DeleteTag(tag)
+		h.checkDeleted("DeleteTag "+tag, seq, v, func(_ string, it *item) bool { return it.tag == tag })
+	case op < 97:
+		seq, v := h.seq.Add(1), currentVersion()
+		Clear()
+		h.checkDeleted("Clear", seq, v, func(string, *item) bool { return true })
+	default:
+		GC()
+	}
+}
+
+// TestConcurrentInvariants runs random interleavings of operations on every engine and checks that
+// a value is never returned after it expired, that DeleteTag and Clear remove every entry set before them,
+// and that a tag limit holds once the operations stop.
+func TestConcurrentInvariants(t *testing.T) {
+	seed := time.Now().UnixNano()
+	t.Logf("seed %d", seed)
+
+	old := CurrentEngine()
+	t.Cleanup(func() { SetEngine(old) })
+
+	steps := 20000
+	if testing.Short() {
+		steps = 2000
+	}
+
+	for _, e := range benchEngines {
+		t.Run(e.String(), func(t *testing.T) {
+			SetEngine(e)
+
+			h := &harness{t: t, prefix: t.Name() + "/"}
+			for i := 0; i < 32; i++ {
+				h.keys = append(h.keys, h.prefix+strconv.Itoa(i))
+			}
+			for i := 0; i < 4; i++ {
+				h.tags = append(h.tags, t.Name()+":"+strconv.Itoa(i))
+			}
+			t.Cleanup(func() { DeleteKeys(h.keys...) })
+
+			const maxEntries = 4
+			limited := h.tags[0]
+			ConfigureTag(limited, TagConfig{MaxEntries: maxEntries})
+			t.Cleanup(func() { ConfigureTag(limited, TagConfig{}) })
+
+			var wg sync.WaitGroup
+			for w := 0; w < 4; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					r := rand.New(rand.NewSource(seed + int64(w)))
+					for i := 0; i < steps && !t.Failed(); i++ {
+						h.step(r)
+					}
+				}(w)
+			}
+			wg.Wait()
+
+			n := 0
+			for _, k := range h.keys {
+				if it, ok := loadItem(k); ok && it.tag == limited && !it.Expired() {
+					n++
+				}
+			}
+			if n > maxEntries {
+				t.Errorf("%s holds %d live entries, limit is %d", limited, n, maxEntries)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package cachestore
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+const maxCallerFrames = 16
+
+var callerFraction atomic.Uint64 // math.Float64bits
+
+// SetTrackCallers records the call site that set an entry for fraction of writes, see EntryMeta.SetBy,
+// zero disables.
+func SetTrackCallers(fraction float64) {
+	callerFraction.Store(math.Float64bits(fraction))
+}
+
+// captureCallers returns the program counters of the caller stack when the write is sampled
+func captureCallers() []uintptr {
+	f := math.Float64frombits(callerFraction.Load())
+	if f <= 0 || (f < 1 && randFloat64() >= f) {
+		return nil
+	}
+	pcs := make([]uintptr, maxCallerFrames)
+	return pcs[:runtime.Callers(3, pcs)]
+}
+
+var pkgPrefix = reflect.TypeOf(item{}).PkgPath() + "."
+
+// callSite returns the first frame in pcs outside this package
+func callSite(pcs []uintptr) string {
+	if len(pcs) == 0 {
+		return ""
+	}
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, pkgPrefix) {
+			return fmt.Sprintf("%s %s:%d", f.Function, f.File, f.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
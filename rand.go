@@ -0,0 +1,34 @@
+package cachestore
+
+import (
+	"math/rand"
+	"sync"
+)
+
+var (
+	randMu sync.Mutex
+	rnd    *rand.Rand
+)
+
+// SetRandSource sets the random source used for early expiration and other randomized behaviors,
+// nil uses the math/rand global source.
+func SetRandSource(src rand.Source) {
+	randMu.Lock()
+	defer randMu.Unlock()
+
+	if src == nil {
+		rnd = nil
+		return
+	}
+	rnd = rand.New(src)
+}
+
+func randFloat64() float64 {
+	randMu.Lock()
+	defer randMu.Unlock()
+
+	if rnd == nil {
+		return rand.Float64()
+	}
+	return rnd.Float64()
+}
@@ -0,0 +1,88 @@
+package cachestore
+
+import "hash/fnv"
+
+// RangeAll calls fn for live entries which value is a T, in no particular order, until fn returns false.
+// It is All for builds before Go 1.23.
+func RangeAll[T any](fn func(key string, value T) bool) {
+	rangeLive(func(it *item) bool { return true }, fn)
+}
+
+// RangeByTag calls fn for live entries with tag which value is a T, in no particular order, until fn returns false.
+// It is ByTag for builds before Go 1.23.
+func RangeByTag[T any](tag string, fn func(key string, value T) bool) {
+	rangeLive(func(it *item) bool { return it.tag == tag }, fn)
+}
+
+func rangeLive[T any](match func(it *item) bool, fn func(key string, value T) bool) {
+	if isDisabled() {
+		return
+	}
+
+	seen := make(map[string]struct{})
+	rangeItems(func(_ engine, key string, it *item) bool {
+		if _, ok := seen[key]; ok { // shadowed by current generation
+			return true
+		}
+		seen[key] = struct{}{}
+		if it.Expired() || it.err != nil || it.Outdated() || !match(it) {
+			return true
+		}
+		data, err := loadTransform(it.data)
+		if err != nil {
+			return true
+		}
+		v, ok := data.(T)
+		if !ok {
+			return true
+		}
+		return fn(key, v)
+	})
+}
+
+// RangePartitionKeys calls fn for keys set by SetWarmKeys and cached keys that fall in partition i of n
+// until fn returns false, partitions are disjoint and stable across processes,
+// so n replicas can each warm partition i without coordination.
+// It is PartitionKeys for builds before Go 1.23.
+func RangePartitionKeys(n, i int, fn func(key string) bool) {
+	if n <= 0 || i < 0 || i >= n {
+		return
+	}
+
+	seen := make(map[string]struct{})
+	emit := func(key string) bool {
+		if _, ok := seen[key]; ok {
+			return true
+		}
+		seen[key] = struct{}{}
+		if keyPartition(key, n) != i {
+			return true
+		}
+		return fn(key)
+	}
+
+	warmKeysMu.RLock()
+	keys := append([]string(nil), warmKeys...)
+	warmKeysMu.RUnlock()
+	for _, k := range keys {
+		if !emit(normalizeKey(k)) {
+			return
+		}
+	}
+
+	if isDisabled() {
+		return
+	}
+	rangeItems(func(_ engine, key string, it *item) bool {
+		if it.Expired() || it.err != nil || it.Outdated() {
+			return true
+		}
+		return emit(key)
+	})
+}
+
+func keyPartition(key string, n int) int {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int(h.Sum64() % uint64(n))
+}
@@ -0,0 +1,65 @@
+package cachestore
+
+import "testing"
+
+func TestRangeByTag(t *testing.T) {
+	defer DeleteTag("range-tag")
+	Set("range-a", 1, WithTag("range-tag"))
+	Set("range-b", "b", WithTag("range-tag"))
+	Set("range-c", 3)
+	defer Delete("range-c")
+
+	got := map[string]int{}
+	RangeByTag("range-tag", func(key string, v int) bool {
+		got[key] = v
+		return true
+	})
+	if len(got) != 1 || got["range-a"] != 1 {
+		t.Fatalf("RangeByTag = %v, want range-a only", got)
+	}
+
+	n := 0
+	RangeAll(func(string, int) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Fatalf("RangeAll called fn %d times after it returned false", n)
+	}
+}
+
+func TestRangePartitionKeysDisjoint(t *testing.T) {
+	keys := []string{"part-a", "part-b", "part-c", "part-d", "part-e"}
+	for _, k := range keys {
+		Set(k, 1)
+	}
+	defer DeleteKeys(keys...)
+
+	seen := map[string]int{}
+	for i := 0; i < 3; i++ {
+		RangePartitionKeys(3, i, func(key string) bool {
+			seen[key]++
+			return true
+		})
+	}
+	for _, k := range keys {
+		if seen[k] != 1 {
+			t.Errorf("%s in %d partitions, want 1", k, seen[k])
+		}
+	}
+}
+
+func TestRangePartitionKeysSkipsOutdated(t *testing.T) {
+	defer SetCurrentSchema(currentSchema())
+	key := t.Name()
+	defer Delete(key)
+
+	Set(key, 1)
+	SetCurrentSchema(currentSchema() + 1)
+	RangePartitionKeys(1, 0, func(k string) bool {
+		if k == key {
+			t.Fatal("partition holds a key outdated by schema")
+		}
+		return true
+	})
+}
@@ -0,0 +1,94 @@
+package cachestore
+
+import (
+	"sync"
+	"time"
+)
+
+// Rate allows Limit events every Per, with bursts of up to Burst events
+type Rate struct {
+	Limit int
+	Per   time.Duration
+	Burst int // zero means Limit
+}
+
+func (r Rate) burst() float64 {
+	if r.Burst > 0 {
+		return float64(r.Burst)
+	}
+	return float64(r.Limit)
+}
+
+// refill returns the time taken to refill an empty bucket
+func (r Rate) refill() time.Duration {
+	return time.Duration(r.burst() / float64(r.Limit) * float64(r.Per))
+}
+
+// buckets holds token buckets by normalized key, apart from cache entries
+var buckets sync.Map // string => *bucket
+
+type bucket struct {
+	mu        sync.Mutex
+	tokens    float64
+	last      time.Time
+	expiresAt time.Time // the bucket is full again
+	deleted   bool      // removed from buckets by GC
+}
+
+// Allow reports whether an event for key is allowed by r, it is AllowN with n of 1
+func Allow(key string, r Rate) bool {
+	return AllowN(key, r, 1)
+}
+
+// AllowN reports whether n events for key are allowed by r and takes them when allowed.
+//
+// The token bucket of key is kept apart from cache entries, Set, Delete and Clear do not affect it,
+// GC removes buckets once they would be full again.
+// Every event is allowed while the cache is disabled.
+func AllowN(key string, r Rate, n int) bool {
+	if r.Limit <= 0 || r.Per <= 0 {
+		return false
+	}
+	if isDisabled() {
+		return true
+	}
+
+	key = normalizeKey(key)
+	for {
+		now := time.Now()
+		v, _ := buckets.LoadOrStore(key, &bucket{tokens: r.burst(), last: now})
+		b := v.(*bucket)
+
+		b.mu.Lock()
+		if b.deleted {
+			b.mu.Unlock()
+			continue
+		}
+		b.tokens += now.Sub(b.last).Seconds() * float64(r.Limit) / r.Per.Seconds()
+		if burst := r.burst(); b.tokens > burst {
+			b.tokens = burst
+		}
+		b.last = now
+		ok := b.tokens >= float64(n)
+		if ok {
+			b.tokens -= float64(n)
+		}
+		b.expiresAt = now.Add(r.refill())
+		b.mu.Unlock()
+		return ok
+	}
+}
+
+// pruneBuckets removes buckets full again by now
+func pruneBuckets(now time.Time) {
+	buckets.Range(func(key, v any) bool {
+		b := v.(*bucket)
+		b.mu.Lock()
+		if now.After(b.expiresAt) {
+			b.deleted = true
+			buckets.CompareAndDelete(key, b)
+		}
+		b.mu.Unlock()
+		return true
+	})
+}
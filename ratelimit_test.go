@@ -0,0 +1,50 @@
+package cachestore
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAllowNKeptApartFromEntries(t *testing.T) {
+	key := t.Name()
+	t.Cleanup(func() {
+		Delete(key)
+		buckets.Delete(key)
+	})
+
+	r := Rate{Limit: 2, Per: time.Hour}
+	if !Allow(key, r) || !Allow(key, r) {
+		t.Fatal("burst denied")
+	}
+	Set(key, "value")
+	if Allow(key, r) {
+		t.Fatal("Set of the same key reset the bucket")
+	}
+	if v, ok := Get[string](key); !ok || v != "value" {
+		t.Fatalf("entry: got %q, %v", v, ok)
+	}
+}
+
+func TestAllowNRacingReaders(t *testing.T) {
+	key := t.Name()
+	t.Cleanup(func() { buckets.Delete(key) })
+
+	r := Rate{Limit: 1000, Per: time.Second}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			Allow(key, r)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			MemoryUsage()
+			GC()
+		}
+	}()
+	wg.Wait()
+}
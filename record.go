@@ -0,0 +1,191 @@
+package cachestore
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type recordedEvent struct {
+	Time  time.Time       `json:"time"`
+	Op    string          `json:"op"` // set, delete or expire
+	Key   string          `json:"key"`
+	Tag   string          `json:"tag,omitempty"`
+	TTL   time.Duration   `json:"ttl,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+
+	Type    string `json:"type,omitempty"` // see RegisterType
+	Version int    `json:"version,omitempty"`
+}
+
+var (
+	recording uint32
+	recordMu  sync.Mutex
+	recordEnc *json.Encoder
+	recordErr error
+)
+
+// StartRecording writes every Set, delete and expiry to w as JSON lines until StopRecording,
+// values are encoded with encoding/json.
+func StartRecording(w io.Writer) {
+	recordMu.Lock()
+	defer recordMu.Unlock()
+
+	recordEnc = json.NewEncoder(w)
+	recordErr = nil
+	atomic.StoreUint32(&recording, 1)
+}
+
+// StopRecording stops recording and returns the first error met while recording,
+// events with values that can not be encoded are skipped and reported.
+func StopRecording() error {
+	recordMu.Lock()
+	defer recordMu.Unlock()
+
+	atomic.StoreUint32(&recording, 0)
+	recordEnc = nil
+	return recordErr
+}
+
+func writeRecord(e recordedEvent) {
+	recordMu.Lock()
+	defer recordMu.Unlock()
+
+	if recordEnc == nil {
+		return
+	}
+	if err := recordEnc.Encode(e); err != nil && recordErr == nil {
+		recordErr = err
+	}
+}
+
+func recordSet(key string, it *item, value any) {
+	if atomic.LoadUint32(&recording) == 0 {
+		return
+	}
+
+	e := recordedEvent{
+		Time: it.createdAt,
+		Op:   "set",
+		Key:  key,
+		Tag:  it.tag,
+	}
+	if !it.expiresAt.IsZero() {
+		e.TTL = it.expiresAt.Sub(it.createdAt)
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		recordMu.Lock()
+		if recordErr == nil {
+			recordErr = err
+		}
+		recordMu.Unlock()
+		return
+	}
+	e.Value = b
+	if name, version, ok := registeredTypeOf(value); ok {
+		e.Type, e.Version = name, version
+	}
+	writeRecord(e)
+}
+
+func recordRemove(key string, expired bool) {
+	if atomic.LoadUint32(&recording) == 0 {
+		return
+	}
+
+	op := "delete"
+	if expired {
+		op = "expire"
+	}
+	writeRecord(recordedEvent{
+		Time: time.Now(),
+		Op:   op,
+		Key:  key,
+	})
+}
+
+type ReplayTTL int
+
+const (
+	ResetTTL         ReplayTTL = iota // recorded TTLs apply from the time of replay
+	PreserveDeadline                  // entries expire at their recorded expiry, already expired entries are not set
+)
+
+type ReplayOptions struct {
+	TTL ReplayTTL
+
+	// MaxAge expires entries recorded longer than MaxAge before replay, zero keeps every entry
+	MaxAge time.Duration
+
+	// OnSkip is called with entries that failed to decode, they are skipped instead of failing Replay
+	OnSkip func(key string, err error)
+}
+
+// Replay applies events written by StartRecording to the cache,
+// decode converts each recorded value back to the type the application stores for key.
+//
+// With nil decode values are decoded by the types registered with RegisterType,
+// migrating values recorded by older versions.
+//
+// TTLs are applied from the time of replay unless opts says otherwise.
+func Replay(r io.Reader, decode func(key string, value json.RawMessage) (any, error), opts ...ReplayOptions) error {
+	var opt ReplayOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var e recordedEvent
+		err := dec.Decode(&e)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch e.Op {
+		case "set":
+			ttl, ok := opt.ttl(e)
+			if !ok {
+				deleteItem(e.Key)
+				continue
+			}
+			var v any
+			if decode != nil {
+				v, err = decode(e.Key, e.Value)
+			} else {
+				v, err = decodeRegistered(e.Type, e.Version, e.Value)
+			}
+			if err != nil {
+				if opt.OnSkip == nil {
+					return err
+				}
+				opt.OnSkip(e.Key, err)
+				deleteItem(e.Key)
+				continue
+			}
+			Set(e.Key, v, &SetOptions{Tag: e.Tag, TTL: ttl})
+		case "delete", "expire":
+			deleteItem(e.Key)
+		}
+	}
+}
+
+// ttl returns the TTL to set e with, or false when e must be expired
+func (opt ReplayOptions) ttl(e recordedEvent) (time.Duration, bool) {
+	if opt.MaxAge > 0 && time.Since(e.Time) > opt.MaxAge {
+		return 0, false
+	}
+	if opt.TTL != PreserveDeadline || e.TTL == 0 {
+		return e.TTL, true
+	}
+	ttl := time.Until(e.Time.Add(e.TTL))
+	return ttl, ttl > 0
+}
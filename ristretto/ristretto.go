@@ -0,0 +1,229 @@
+// Package ristretto provides the Cache API of github.com/dgraph-io/ristretto/v2 backed by cachestore,
+// so code using ristretto can move to cachestore by changing the import.
+//
+// Entries live in the cachestore package store, cost and admission settings are accepted but not enforced,
+// costs are only summed in Metrics.
+package ristretto
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/moonrhythm/cachestore"
+)
+
+var nextID atomic.Uint64
+
+// Key matches the key types of ristretto
+type Key interface {
+	~uint64 | ~string | ~[]byte | ~byte | ~int | ~int32 | ~uint32 | ~int64
+}
+
+// Config holds the ristretto settings this package accepts,
+// NumCounters, MaxCost and BufferItems are validated like ristretto but otherwise unused,
+// nothing is evicted for cost. Metrics enables Cache.Metrics, Cost computes the cost of Set with zero cost
+// for Metrics.CostAdded, IgnoreInternalCost has no effect as there is no internal cost.
+type Config[K Key, V any] struct {
+	NumCounters        int64
+	MaxCost            int64
+	BufferItems        int64
+	Metrics            bool
+	IgnoreInternalCost bool
+	Cost               func(value V) int64
+}
+
+type Cache[K Key, V any] struct {
+	// Metrics counts cache operations, nil unless Config.Metrics is set
+	Metrics *Metrics
+
+	prefix  string
+	cost    func(value V) int64
+	maxCost atomic.Int64
+	closed  atomic.Bool
+}
+
+// Metrics is the subset of ristretto metrics this package counts, a nil Metrics reports zero
+type Metrics struct {
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	keysAdded atomic.Uint64
+	costAdded atomic.Uint64
+}
+
+func (m *Metrics) Hits() uint64 {
+	if m == nil {
+		return 0
+	}
+	return m.hits.Load()
+}
+
+func (m *Metrics) Misses() uint64 {
+	if m == nil {
+		return 0
+	}
+	return m.misses.Load()
+}
+
+// KeysAdded returns the number of successful Set calls
+func (m *Metrics) KeysAdded() uint64 {
+	if m == nil {
+		return 0
+	}
+	return m.keysAdded.Load()
+}
+
+// CostAdded returns the sum of costs of successful Set calls
+func (m *Metrics) CostAdded() uint64 {
+	if m == nil {
+		return 0
+	}
+	return m.costAdded.Load()
+}
+
+// Ratio returns hits over hits and misses
+func (m *Metrics) Ratio() float64 {
+	hits, misses := m.Hits(), m.Misses()
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// Clear resets every counter
+func (m *Metrics) Clear() {
+	if m == nil {
+		return
+	}
+	m.hits.Store(0)
+	m.misses.Store(0)
+	m.keysAdded.Store(0)
+	m.costAdded.Store(0)
+}
+
+// NewCache returns a cache storing entries under its own prefix in cachestore
+func NewCache[K Key, V any](config *Config[K, V]) (*Cache[K, V], error) {
+	switch {
+	case config.NumCounters == 0:
+		return nil, errors.New("NumCounters can't be zero")
+	case config.MaxCost == 0:
+		return nil, errors.New("MaxCost can't be zero")
+	case config.BufferItems == 0:
+		return nil, errors.New("BufferItems can't be zero")
+	}
+	c := Cache[K, V]{
+		prefix: "ristretto/" + strconv.FormatUint(nextID.Add(1), 10) + "/",
+		cost:   config.Cost,
+	}
+	if config.Metrics {
+		c.Metrics = &Metrics{}
+	}
+	c.maxCost.Store(config.MaxCost)
+	return &c, nil
+}
+
+func (c *Cache[K, V]) key(key K) string {
+	v := reflect.ValueOf(key)
+	switch v.Kind() {
+	case reflect.String:
+		return c.prefix + v.String()
+	case reflect.Slice:
+		return c.prefix + string(v.Bytes())
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return c.prefix + strconv.FormatInt(v.Int(), 10)
+	default:
+		return c.prefix + strconv.FormatUint(v.Uint(), 10)
+	}
+}
+
+// Get returns the value for key
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	if c == nil || c.closed.Load() {
+		return *new(V), false
+	}
+	v, ok := cachestore.Get[V](c.key(key))
+	if c.Metrics != nil {
+		if ok {
+			c.Metrics.hits.Add(1)
+		} else {
+			c.Metrics.misses.Add(1)
+		}
+	}
+	return v, ok
+}
+
+// Set stores value for key without expiry, cost is only counted in Metrics
+func (c *Cache[K, V]) Set(key K, value V, cost int64) bool {
+	return c.SetWithTTL(key, value, cost, 0)
+}
+
+// SetWithTTL stores value for key for ttl, zero ttl never expires and negative ttl stores nothing,
+// cost is only counted in Metrics
+func (c *Cache[K, V]) SetWithTTL(key K, value V, cost int64, ttl time.Duration) bool {
+	if c == nil || c.closed.Load() || ttl < 0 {
+		return false
+	}
+	cachestore.Set(c.key(key), value, cachestore.WithTTL(ttl))
+	if c.Metrics != nil {
+		if cost == 0 && c.cost != nil {
+			cost = c.cost(value)
+		}
+		c.Metrics.keysAdded.Add(1)
+		c.Metrics.costAdded.Add(uint64(max(cost, 0)))
+	}
+	return true
+}
+
+func (c *Cache[K, V]) Del(key K) {
+	if c == nil || c.closed.Load() {
+		return
+	}
+	cachestore.Delete(c.key(key))
+}
+
+// GetTTL returns the remaining TTL of key, zero for keys without expiry
+func (c *Cache[K, V]) GetTTL(key K) (time.Duration, bool) {
+	if c == nil || c.closed.Load() {
+		return 0, false
+	}
+	k := c.key(key)
+	ttl, ok := cachestore.MTTL([]string{k})[k]
+	return ttl, ok
+}
+
+// Wait returns immediately, writes are applied before Set returns
+func (c *Cache[K, V]) Wait() {}
+
+// Clear deletes every entry of c
+func (c *Cache[K, V]) Clear() {
+	if c == nil || c.closed.Load() {
+		return
+	}
+	cachestore.DeletePrefix(c.prefix)
+}
+
+// Close deletes every entry of c, c must not be used after Close
+func (c *Cache[K, V]) Close() {
+	if c == nil || c.closed.Load() {
+		return
+	}
+	c.Clear()
+	c.closed.Store(true)
+}
+
+func (c *Cache[K, V]) MaxCost() int64 {
+	if c == nil {
+		return 0
+	}
+	return c.maxCost.Load()
+}
+
+// UpdateMaxCost records maxCost, it is not enforced
+func (c *Cache[K, V]) UpdateMaxCost(maxCost int64) {
+	if c == nil {
+		return
+	}
+	c.maxCost.Store(maxCost)
+}
@@ -0,0 +1,118 @@
+package ristretto
+
+import (
+	"testing"
+	"time"
+)
+
+func newCache[K Key, V any](t *testing.T, config *Config[K, V]) *Cache[K, V] {
+	t.Helper()
+	if config == nil {
+		config = &Config[K, V]{}
+	}
+	config.NumCounters, config.MaxCost, config.BufferItems = 1e4, 1<<20, 64
+	c, err := NewCache(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestNewCacheValidates(t *testing.T) {
+	for _, config := range []*Config[string, int]{
+		{MaxCost: 1, BufferItems: 1},
+		{NumCounters: 1, BufferItems: 1},
+		{NumCounters: 1, MaxCost: 1},
+	} {
+		if _, err := NewCache(config); err == nil {
+			t.Errorf("NewCache(%+v) got no error", *config)
+		}
+	}
+}
+
+func TestGetSetDel(t *testing.T) {
+	c := newCache[string, int](t, nil)
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("got a hit before Set")
+	}
+	if !c.Set("a", 1, 1) {
+		t.Fatal("Set got rejected")
+	}
+	c.Wait()
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("got %d, %v, want 1", v, ok)
+	}
+	if ttl, ok := c.GetTTL("a"); !ok || ttl != 0 {
+		t.Fatalf("got ttl %v, %v, want no expiry", ttl, ok)
+	}
+
+	c.Del("a")
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("got a hit after Del")
+	}
+}
+
+func TestSetWithTTL(t *testing.T) {
+	c := newCache[uint64, string](t, nil)
+
+	if c.SetWithTTL(1, "x", 1, -time.Second) {
+		t.Fatal("got negative ttl stored")
+	}
+	c.SetWithTTL(1, "x", 1, 10*time.Millisecond)
+	if ttl, ok := c.GetTTL(1); !ok || ttl <= 0 || ttl > 10*time.Millisecond {
+		t.Fatalf("got ttl %v, %v, want up to 10ms", ttl, ok)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := c.Get(1); ok {
+		t.Fatal("got a hit after ttl")
+	}
+}
+
+func TestCachesAreSeparate(t *testing.T) {
+	a := newCache[[]byte, int](t, nil)
+	b := newCache[[]byte, int](t, nil)
+
+	a.Set([]byte("k"), 1, 1)
+	b.Set([]byte("k"), 2, 1)
+	a.Clear()
+	if _, ok := a.Get([]byte("k")); ok {
+		t.Fatal("got a hit after Clear")
+	}
+	if v, ok := b.Get([]byte("k")); !ok || v != 2 {
+		t.Fatalf("got %d, %v from the other cache, want 2", v, ok)
+	}
+
+	b.Close()
+	if b.Set([]byte("k"), 3, 1) {
+		t.Fatal("Set after Close got stored")
+	}
+	if _, ok := b.Get([]byte("k")); ok {
+		t.Fatal("got a hit after Close")
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	c := newCache(t, &Config[int, string]{
+		Metrics: true,
+		Cost:    func(v string) int64 { return int64(len(v)) },
+	})
+
+	c.Set(1, "abc", 0) // cost from Config.Cost
+	c.Set(2, "x", 5)
+	c.Get(1)
+	c.Get(3)
+	if m := c.Metrics; m.Hits() != 1 || m.Misses() != 1 || m.KeysAdded() != 2 || m.CostAdded() != 8 || m.Ratio() != 0.5 {
+		t.Fatalf("got hits %d misses %d keys %d cost %d ratio %v, want 1 1 2 8 0.5",
+			m.Hits(), m.Misses(), m.KeysAdded(), m.CostAdded(), m.Ratio())
+	}
+	c.Metrics.Clear()
+	if c.Metrics.Hits() != 0 {
+		t.Fatal("got hits after Clear")
+	}
+
+	if m := newCache[int, string](t, nil).Metrics; m != nil || m.Ratio() != 0 {
+		t.Fatal("got Metrics without Config.Metrics")
+	}
+}
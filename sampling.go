@@ -0,0 +1,52 @@
+package cachestore
+
+import (
+	"path"
+	"sync"
+)
+
+type samplingRule struct {
+	pattern  string
+	fraction float64
+}
+
+var (
+	samplingMu    sync.RWMutex
+	samplingRules []samplingRule
+)
+
+// SetSampling makes GetOrSet use the cache for only fraction of calls with key matching pattern,
+// other calls bypass the cache and call the loader directly.
+// pattern is in path.Match syntax, the first matching pattern applies.
+// Fraction 1 or more removes the rule.
+func SetSampling(pattern string, fraction float64) {
+	samplingMu.Lock()
+	defer samplingMu.Unlock()
+
+	rules := make([]samplingRule, 0, len(samplingRules)+1)
+	for _, r := range samplingRules {
+		if r.pattern != pattern {
+			rules = append(rules, r)
+		}
+	}
+	if fraction < 1 {
+		rules = append(rules, samplingRule{pattern: pattern, fraction: fraction})
+	}
+	samplingRules = rules
+}
+
+// bypassCache reports whether this call for key must bypass the cache
+func bypassCache(key string) bool {
+	samplingMu.RLock()
+	defer samplingMu.RUnlock()
+
+	for _, r := range samplingRules {
+		if ok, _ := path.Match(r.pattern, key); ok {
+			if isDeterministic() {
+				return keyFraction(key) >= r.fraction
+			}
+			return randFloat64() >= r.fraction
+		}
+	}
+	return false
+}
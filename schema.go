@@ -0,0 +1,19 @@
+package cachestore
+
+import "sync/atomic"
+
+var schema atomic.Int64
+
+// SetCurrentSchema sets the current schema version,
+// entries written with an older schema version are treated as missing.
+func SetCurrentSchema(v int) {
+	schema.Store(int64(v))
+}
+
+func currentSchema() int {
+	return int(schema.Load())
+}
+
+func (it *item) Outdated() bool {
+	return it.schema < currentSchema()
+}
@@ -0,0 +1,23 @@
+package cachestore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFirstWriteWinsReplacesOutdated(t *testing.T) {
+	defer SetCurrentSchema(currentSchema())
+	defer Delete("fww-outdated")
+
+	Set("fww-outdated", 1)
+	SetCurrentSchema(currentSchema() + 1)
+	Set("fww-outdated", 2, WithFirstWriteWins(time.Minute))
+	if v, ok := Get[int]("fww-outdated"); !ok || v != 2 {
+		t.Fatalf("Get = %v, %v, want 2 replacing the outdated entry", v, ok)
+	}
+
+	Set("fww-outdated", 3, WithFirstWriteWins(time.Minute))
+	if v, _ := Get[int]("fww-outdated"); v != 2 {
+		t.Fatalf("Get = %v, want the first write 2 kept", v)
+	}
+}
@@ -0,0 +1,50 @@
+package cachestore
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var staleServed atomic.Uint64
+
+// StaleServed returns the number of stale values returned by GetOrSet because loading failed
+func StaleServed() uint64 {
+	return staleServed.Load()
+}
+
+// serveStale returns the stale value for key after loading it failed with err,
+// within opt.MaxServeStale after expiry when set
+func serveStale[T any](key string, err error, opt *SetOptions) (Result[T], bool) {
+	if err == nil || isDisabled() {
+		return Result[T]{}, false
+	}
+	var maxStale time.Duration
+	if opt != nil {
+		maxStale = opt.MaxServeStale
+	}
+	timedOut := errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrDeadlineTooShort)
+	if maxStale <= 0 && !timedOut {
+		return Result[T]{}, false
+	}
+
+	it, ok := loadItem(key)
+	if !ok {
+		return Result[T]{}, false
+	}
+	if it.err != nil && it.stale != nil {
+		it = it.stale
+	}
+	if maxStale > 0 && it.Expired() && time.Since(it.expiresAt) > maxStale {
+		return Result[T]{}, false
+	}
+	r, ok, mismatch := itemResult[T](key, it, Stale)
+	if mismatch != nil {
+		return Result[T]{}, false
+	}
+	if ok {
+		staleServed.Add(1)
+	}
+	return r, ok
+}
@@ -0,0 +1,64 @@
+package cachestore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMaxServeStaleAfterGC(t *testing.T) {
+	key := t.Name()
+	t.Cleanup(func() { Delete(key) })
+
+	ctx := context.Background()
+	opts := []Option{WithTTL(10 * time.Millisecond), WithMaxServeStale(time.Hour)}
+	_, err := GetOrSet(ctx, key, func(context.Context) (string, error) {
+		return "stale", nil
+	}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	GC()
+
+	v, err := GetOrSet(ctx, key, func(context.Context) (string, error) {
+		return "", errors.New("backend down")
+	}, opts...)
+	if err != nil {
+		t.Fatalf("got error %v, want stale value", err)
+	}
+	if v != "stale" {
+		t.Fatalf("got %q, want %q", v, "stale")
+	}
+}
+
+func TestMaxServeStaleWithErrorTTL(t *testing.T) {
+	key := t.Name()
+	t.Cleanup(func() { Delete(key) })
+
+	ctx := context.Background()
+	opts := []Option{WithTTL(10 * time.Millisecond), WithMaxServeStale(time.Hour), WithErrorTTL(time.Minute)}
+	if _, err := GetOrSet(ctx, key, func(context.Context) (string, error) {
+		return "stale", nil
+	}, opts...); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	fail := func(context.Context) (string, error) {
+		return "", errors.New("backend down")
+	}
+	// the first failure caches the error, later calls hit the cached error
+	for i := 0; i < 2; i++ {
+		v, err := GetOrSet(ctx, key, fail, opts...)
+		if err != nil || v != "stale" {
+			t.Fatalf("call %d: got %q, %v, want the stale value", i, v, err)
+		}
+	}
+	GC()
+	if v, err := GetOrSet(ctx, key, fail, opts...); err != nil || v != "stale" {
+		t.Fatalf("after GC: got %q, %v, want the stale value", v, err)
+	}
+}
@@ -0,0 +1,113 @@
+// Package session stores sessions in cachestore with sliding expiry
+// and an optional persistent backend behind it.
+package session
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/moonrhythm/cachestore"
+)
+
+const keyPrefix = "session/"
+
+type Values map[string]any
+
+func (v Values) clone() Values {
+	c := make(Values, len(v))
+	for k, x := range v {
+		c[k] = x
+	}
+	return c
+}
+
+// Backend persists sessions beyond the cache, such as in a database
+type Backend interface {
+	Load(ctx context.Context, id string) (Values, bool, error)
+	Save(ctx context.Context, id string, values Values, ttl time.Duration) error
+	Delete(ctx context.Context, id string) error
+}
+
+// entry is a cached session
+type entry struct {
+	values Values
+	saved  atomic.Int64 // unix nanoseconds of the last save to the backend
+}
+
+func newEntry(values Values) *entry {
+	return &entry{values: values.clone()}
+}
+
+type Store struct {
+	ttl     time.Duration
+	backend Backend
+}
+
+// New returns a store keeping sessions for ttl since last access, backend may be nil
+func New(ttl time.Duration, backend Backend) *Store {
+	return &Store{ttl: ttl, backend: backend}
+}
+
+// Get returns values of session id and extends its expiry,
+// a session missing from the cache is loaded from the backend.
+//
+// The expiry in the backend is extended by saving the session again,
+// at most once every half ttl. A failed save is retried on the next Get and does not fail Get.
+func (s *Store) Get(ctx context.Context, id string) (Values, bool, error) {
+	if e, ok := cachestore.GetAndTouch[*entry](keyPrefix+id, s.ttl); ok {
+		s.slide(ctx, id, e)
+		return e.values.clone(), true, nil
+	}
+	if s.backend == nil {
+		return nil, false, nil
+	}
+
+	v, ok, err := s.backend.Load(ctx, id)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	e := newEntry(v)
+	s.slide(ctx, id, e)
+	cachestore.Set(keyPrefix+id, e, cachestore.WithTTL(s.ttl))
+	return v, true, nil
+}
+
+// slide saves e to the backend again unless it was saved within half ttl
+func (s *Store) slide(ctx context.Context, id string, e *entry) {
+	if s.backend == nil {
+		return
+	}
+	now := time.Now().UnixNano()
+	last := e.saved.Load()
+	if now-last < int64(s.ttl/2) || !e.saved.CompareAndSwap(last, now) {
+		return
+	}
+	if err := s.backend.Save(ctx, id, e.values.clone(), s.ttl); err != nil {
+		e.saved.CompareAndSwap(now, last)
+	}
+}
+
+// Save stores values of session id, in the backend first
+func (s *Store) Save(ctx context.Context, id string, values Values) error {
+	e := newEntry(values)
+	if s.backend != nil {
+		if err := s.backend.Save(ctx, id, values, s.ttl); err != nil {
+			return err
+		}
+		e.saved.Store(time.Now().UnixNano())
+	}
+	cachestore.Set(keyPrefix+id, e, cachestore.WithTTL(s.ttl))
+	return nil
+}
+
+// Destroy deletes session id from the backend and the cache,
+// the cached session is deleted even when the backend fails.
+func (s *Store) Destroy(ctx context.Context, id string) error {
+	var err error
+	if s.backend != nil {
+		err = s.backend.Delete(ctx, id)
+	}
+	cachestore.Delete(keyPrefix + id)
+	return err
+}
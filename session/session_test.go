@@ -0,0 +1,75 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memBackend struct {
+	mu    sync.Mutex
+	data  map[string]Values
+	saves int
+}
+
+func (b *memBackend) Load(_ context.Context, id string) (Values, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	v, ok := b.data[id]
+	return v, ok, nil
+}
+
+func (b *memBackend) Save(_ context.Context, id string, values Values, _ time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data[id] = values
+	b.saves++
+	return nil
+}
+
+func (b *memBackend) Delete(_ context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.data, id)
+	return nil
+}
+
+func (b *memBackend) saveCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.saves
+}
+
+func TestGetSlidesBackendExpiry(t *testing.T) {
+	ctx := context.Background()
+	b := &memBackend{data: map[string]Values{"loaded": {"a": 1}}}
+	s := New(40*time.Millisecond, b)
+	defer s.Destroy(ctx, "saved")
+	defer s.Destroy(ctx, "loaded")
+
+	// a backend hit is saved back to extend its expiry
+	if _, ok, err := s.Get(ctx, "loaded"); !ok || err != nil {
+		t.Fatalf("Get loaded = %v, %v", ok, err)
+	}
+	if n := b.saveCount(); n != 1 {
+		t.Fatalf("saves after backend hit = %d, want 1", n)
+	}
+
+	if err := s.Save(ctx, "saved", Values{"b": 2}); err != nil {
+		t.Fatal(err)
+	}
+	// hits within half ttl of the last save do not save again
+	s.Get(ctx, "saved")
+	if n := b.saveCount(); n != 2 {
+		t.Fatalf("saves after early hit = %d, want 2", n)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if _, ok, _ := s.Get(ctx, "saved"); !ok {
+		t.Fatal("session expired while sliding")
+	}
+	if n := b.saveCount(); n != 3 {
+		t.Fatalf("saves after late hit = %d, want 3", n)
+	}
+}
@@ -0,0 +1,96 @@
+package simulate
+
+import (
+	"container/heap"
+	"time"
+)
+
+type gdsEntry struct {
+	key     string
+	addedAt time.Time
+	size    int
+	h       float64
+	index   int
+}
+
+type gdsHeap []*gdsEntry
+
+func (h gdsHeap) Len() int           { return len(h) }
+func (h gdsHeap) Less(i, j int) bool { return h[i].h < h[j].h }
+func (h gdsHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *gdsHeap) Push(x any) {
+	e := x.(*gdsEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *gdsHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+type gds struct {
+	capacity int
+	size     func(key string) int
+	used     int
+	inflate  float64 // L, the priority of the last evicted entry
+	h        gdsHeap
+	items    map[string]*gdsEntry
+}
+
+func (p *gds) priority(size int) float64 {
+	return p.inflate + 1/float64(size)
+}
+
+func (p *gds) Get(key string) (time.Time, bool) {
+	e, ok := p.items[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	e.h = p.priority(e.size)
+	heap.Fix(&p.h, e.index)
+	return e.addedAt, true
+}
+
+func (p *gds) Add(key string, t time.Time) {
+	if e, ok := p.items[key]; ok {
+		e.addedAt = t
+		e.h = p.priority(e.size)
+		heap.Fix(&p.h, e.index)
+		return
+	}
+
+	size := p.size(key)
+	if size <= 0 {
+		size = 1
+	}
+	if size > p.capacity {
+		return
+	}
+	for p.used+size > p.capacity {
+		e := heap.Pop(&p.h).(*gdsEntry)
+		p.inflate = e.h
+		p.used -= e.size
+		delete(p.items, e.key)
+	}
+	e := &gdsEntry{key: key, addedAt: t, size: size, h: p.priority(size)}
+	heap.Push(&p.h, e)
+	p.items[key] = e
+	p.used += size
+}
+
+// GreedyDualSize evicts the key with the lowest priority, where priority is
+// 1/size raised on every access to above every evicted key, so large cold keys go first.
+// Capacity is in units of size.
+func GreedyDualSize(size func(key string) int) NewPolicy {
+	return func(capacity int) Policy {
+		return &gds{capacity: capacity, size: size, items: map[string]*gdsEntry{}}
+	}
+}
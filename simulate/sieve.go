@@ -0,0 +1,69 @@
+package simulate
+
+import (
+	"container/list"
+	"time"
+)
+
+type sieveEntry struct {
+	key     string
+	addedAt time.Time
+	visited bool
+}
+
+type sieve struct {
+	capacity int
+	ll       *list.List // newest first
+	items    map[string]*list.Element
+	hand     *list.Element // next candidate for eviction, nil starts from the oldest
+}
+
+func (p *sieve) Get(key string) (time.Time, bool) {
+	e, ok := p.items[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	se := e.Value.(*sieveEntry)
+	se.visited = true
+	return se.addedAt, true
+}
+
+func (p *sieve) Add(key string, t time.Time) {
+	if e, ok := p.items[key]; ok {
+		se := e.Value.(*sieveEntry)
+		se.addedAt = t
+		se.visited = true
+		return
+	}
+	if p.capacity <= 0 {
+		return
+	}
+	for p.ll.Len() >= p.capacity {
+		p.evict()
+	}
+	p.items[key] = p.ll.PushFront(&sieveEntry{key: key, addedAt: t})
+}
+
+// evict moves the hand from the oldest towards the newest key, clearing visited keys,
+// and evicts the first key not visited since the hand last passed it
+func (p *sieve) evict() {
+	e := p.hand
+	if e == nil {
+		e = p.ll.Back()
+	}
+	for e.Value.(*sieveEntry).visited {
+		e.Value.(*sieveEntry).visited = false
+		if e = e.Prev(); e == nil {
+			e = p.ll.Back()
+		}
+	}
+	p.hand = e.Prev()
+	p.ll.Remove(e)
+	delete(p.items, e.Value.(*sieveEntry).key)
+}
+
+// SIEVE evicts keys in insertion order like FIFO but keeps keys accessed since the eviction hand passed them,
+// the hand sweeps from the oldest key to the newest and keys are never moved on access.
+func SIEVE(capacity int) Policy {
+	return &sieve{capacity: capacity, ll: list.New(), items: map[string]*list.Element{}}
+}
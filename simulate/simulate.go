@@ -0,0 +1,165 @@
+// Package simulate replays access logs against cache policies to estimate hit ratios
+package simulate
+
+import (
+	"container/list"
+	"encoding/csv"
+	"errors"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type Access struct {
+	Key  string
+	Time time.Time
+}
+
+// Policy is a bounded set of keys that evicts keys when full
+type Policy interface {
+	// Get returns the time key was added, recording the access
+	Get(key string) (time.Time, bool)
+
+	// Add adds or replaces key
+	Add(key string, t time.Time)
+}
+
+type NewPolicy func(capacity int) Policy
+
+type entry struct {
+	key     string
+	addedAt time.Time
+}
+
+type lru struct {
+	capacity  int
+	ll        *list.List
+	items     map[string]*list.Element
+	moveOnGet bool
+}
+
+func (p *lru) Get(key string) (time.Time, bool) {
+	e, ok := p.items[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	if p.moveOnGet {
+		p.ll.MoveToFront(e)
+	}
+	return e.Value.(*entry).addedAt, true
+}
+
+func (p *lru) Add(key string, t time.Time) {
+	if e, ok := p.items[key]; ok {
+		e.Value.(*entry).addedAt = t
+		p.ll.MoveToFront(e)
+		return
+	}
+	p.items[key] = p.ll.PushFront(&entry{key: key, addedAt: t})
+	for p.ll.Len() > p.capacity {
+		e := p.ll.Back()
+		p.ll.Remove(e)
+		delete(p.items, e.Value.(*entry).key)
+	}
+}
+
+// LRU evicts the least recently used key
+func LRU(capacity int) Policy {
+	return &lru{capacity: capacity, ll: list.New(), items: map[string]*list.Element{}, moveOnGet: true}
+}
+
+// FIFO evicts the oldest added key
+func FIFO(capacity int) Policy {
+	return &lru{capacity: capacity, ll: list.New(), items: map[string]*list.Element{}}
+}
+
+type Report struct {
+	Policy   string
+	Capacity int
+	Hits     int
+	Misses   int
+}
+
+func (r Report) HitRatio() float64 {
+	if r.Hits+r.Misses == 0 {
+		return 0
+	}
+	return float64(r.Hits) / float64(r.Hits+r.Misses)
+}
+
+// Run replays log against every policy with every capacity, reports are sorted by policy name,
+// an access is a hit when the key was added less than ttl before, zero ttl never expires.
+// A miss adds the key, as a loader would.
+func Run(log []Access, ttl time.Duration, capacities []int, policies map[string]NewPolicy) []Report {
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var rs []Report
+	for _, name := range names {
+		newPolicy := policies[name]
+		for _, c := range capacities {
+			r := Report{Policy: name, Capacity: c}
+			p := newPolicy(c)
+			for _, a := range log {
+				addedAt, ok := p.Get(a.Key)
+				if ok && (ttl <= 0 || a.Time.Sub(addedAt) < ttl) {
+					r.Hits++
+					continue
+				}
+				r.Misses++
+				p.Add(a.Key, a.Time)
+			}
+			rs = append(rs, r)
+		}
+	}
+	return rs
+}
+
+// ReadLog reads an access log in csv, one key,timestamp record per line,
+// timestamp is in RFC 3339 or unix milliseconds.
+func ReadLog(r io.Reader) ([]Access, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 2
+
+	var log []Access
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return log, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		t, err := parseTime(rec[1])
+		if err != nil {
+			return nil, err
+		}
+		log = append(log, Access{Key: rec[0], Time: t})
+	}
+}
+
+func parseTime(s string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// Zipf generates n accesses over keys distinct keys following a zipfian distribution with s > 1,
+// one access per interval starting at start.
+func Zipf(r *rand.Rand, keys int, s float64, n int, start time.Time, interval time.Duration) []Access {
+	z := rand.NewZipf(r, s, 1, uint64(keys-1))
+	log := make([]Access, n)
+	for i := range log {
+		log[i] = Access{
+			Key:  strconv.FormatUint(z.Uint64(), 10),
+			Time: start.Add(time.Duration(i) * interval),
+		}
+	}
+	return log
+}
@@ -0,0 +1,143 @@
+package simulate
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func accesses(start time.Time, keys ...string) []Access {
+	log := make([]Access, len(keys))
+	for i, k := range keys {
+		log[i] = Access{Key: k, Time: start.Add(time.Duration(i) * time.Second)}
+	}
+	return log
+}
+
+func TestLRUAndFIFOHitRatio(t *testing.T) {
+	// a is accessed before c is added, LRU evicts b and FIFO evicts a
+	log := accesses(time.Unix(0, 0), "a", "b", "a", "c", "a", "b")
+	rs := Run(log, 0, []int{2}, map[string]NewPolicy{"lru": LRU, "fifo": FIFO})
+
+	want := []Report{
+		{Policy: "fifo", Capacity: 2, Hits: 1, Misses: 5},
+		{Policy: "lru", Capacity: 2, Hits: 2, Misses: 4},
+	}
+	if !reflect.DeepEqual(rs, want) {
+		t.Fatalf("got %+v, want %+v", rs, want)
+	}
+	if r := rs[1].HitRatio(); r != 2.0/6 {
+		t.Fatalf("got LRU hit ratio %v, want %v", r, 2.0/6)
+	}
+}
+
+func TestRunCapacitiesAndTTL(t *testing.T) {
+	log := accesses(time.Unix(0, 0), "a", "b", "a", "b", "a")
+	rs := Run(log, 0, []int{1, 2}, map[string]NewPolicy{"lru": LRU})
+	if len(rs) != 2 || rs[0].Hits != 0 || rs[1].Hits != 3 {
+		t.Fatalf("got %+v, want 0 hits at capacity 1 and 3 at capacity 2", rs)
+	}
+
+	// a hit needs the key added less than ttl before, a miss adds it again
+	rs = Run(log, 1500*time.Millisecond, []int{2}, map[string]NewPolicy{"lru": LRU})
+	if rs[0].Hits != 0 || rs[0].Misses != 5 {
+		t.Fatalf("got %+v, want every access to miss", rs[0])
+	}
+	rs = Run(log, 3*time.Second, []int{2}, map[string]NewPolicy{"lru": LRU})
+	if rs[0].Hits != 2 || rs[0].Misses != 3 {
+		t.Fatalf("got %+v, want 2 hits and 3 misses", rs[0])
+	}
+
+	if r := (Report{}).HitRatio(); r != 0 {
+		t.Fatalf("got empty hit ratio %v, want 0", r)
+	}
+}
+
+func TestReadLog(t *testing.T) {
+	log, err := ReadLog(strings.NewReader("a,1000\nb,1970-01-01T00:00:02Z\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Access{
+		{Key: "a", Time: time.UnixMilli(1000)},
+		{Key: "b", Time: time.Unix(2, 0).UTC()},
+	}
+	if len(log) != len(want) {
+		t.Fatalf("got %v, want %v", log, want)
+	}
+	for i := range want {
+		if log[i].Key != want[i].Key || !log[i].Time.Equal(want[i].Time) {
+			t.Fatalf("got %v, want %v", log, want)
+		}
+	}
+
+	for _, s := range []string{"a\n", "a,yesterday\n"} {
+		if _, err := ReadLog(strings.NewReader(s)); err == nil {
+			t.Errorf("ReadLog(%q) got no error", s)
+		}
+	}
+}
+
+func TestZipfSkewed(t *testing.T) {
+	start := time.Unix(0, 0)
+	log := Zipf(rand.New(rand.NewSource(1)), 100, 1.5, 1000, start, time.Millisecond)
+	if len(log) != 1000 || !log[999].Time.Equal(start.Add(999*time.Millisecond)) {
+		t.Fatalf("got %d accesses ending at %v", len(log), log[len(log)-1].Time)
+	}
+	n := 0
+	for _, a := range log {
+		if a.Key == "0" {
+			n++
+		}
+	}
+	if n < 300 {
+		t.Fatalf("got %d accesses to the hottest key, want a skewed log", n)
+	}
+}
+
+func TestSIEVEKeepsVisitedKeys(t *testing.T) {
+	p := SIEVE(3)
+	now := time.Now()
+	for _, k := range []string{"a", "b", "c"} {
+		p.Add(k, now)
+	}
+	p.Get("a")
+	p.Add("d", now) // the hand passes visited a and evicts b
+
+	for k, want := range map[string]bool{"a": true, "b": false, "c": true, "d": true} {
+		if _, ok := p.Get(k); ok != want {
+			t.Errorf("%s cached = %v, want %v", k, ok, want)
+		}
+	}
+}
+
+func TestSIEVEBeatsFIFOOnZipf(t *testing.T) {
+	log := Zipf(rand.New(rand.NewSource(1)), 1000, 1.2, 20000, time.Unix(0, 0), time.Millisecond)
+	rs := Run(log, 0, []int{50}, map[string]NewPolicy{"fifo": FIFO, "sieve": SIEVE})
+	fifo, sieve := rs[0], rs[1]
+	if sieve.HitRatio() <= fifo.HitRatio() {
+		t.Fatalf("SIEVE hit ratio %.3f, FIFO %.3f, want SIEVE higher", sieve.HitRatio(), fifo.HitRatio())
+	}
+}
+
+func TestGreedyDualSizeEvictsLargeKeys(t *testing.T) {
+	size := func(key string) int { return len(key) }
+	p := GreedyDualSize(size)(4)
+	now := time.Now()
+	p.Add("a", now)
+	p.Add("bbb", now)
+	p.Add("c", now) // over capacity, bbb has the lowest priority
+
+	for k, want := range map[string]bool{"a": true, "bbb": false, "c": true} {
+		if _, ok := p.Get(k); ok != want {
+			t.Errorf("%s cached = %v, want %v", k, ok, want)
+		}
+	}
+
+	p.Add("toolarge", now)
+	if _, ok := p.Get("toolarge"); ok {
+		t.Fatal("got a key larger than capacity cached")
+	}
+}
@@ -0,0 +1,59 @@
+package cachestore
+
+import (
+	"sync"
+	"time"
+)
+
+// Cached returns a function that returns the value returned by loader, cached for ttl.
+//
+// The first call waits for loader, concurrent calls share it, an error is returned and not cached.
+// After ttl the cached value is returned while loader refreshes it in the background,
+// a failed or panicked refresh keeps the previous value.
+func Cached[T any](ttl time.Duration, loader func() (T, error)) func() (T, error) {
+	var (
+		mu         sync.Mutex
+		value      T
+		loadedAt   time.Time
+		loaded     bool
+		refreshing bool
+	)
+
+	refresh := func() {
+		defer func() {
+			recover()
+			mu.Lock()
+			refreshing = false
+			mu.Unlock()
+		}()
+		v, err := loader()
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		value, loadedAt = v, time.Now()
+		mu.Unlock()
+	}
+
+	return func() (T, error) {
+		if isDisabled() {
+			return loader()
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !loaded {
+			v, err := loader()
+			if err != nil {
+				return v, err
+			}
+			value, loadedAt, loaded = v, time.Now(), true
+			return value, nil
+		}
+		if ttl > 0 && time.Since(loadedAt) >= ttl && !refreshing {
+			refreshing = spawn(refresh) // retried on the next call when the goroutine cap is reached
+		}
+		return value, nil
+	}
+}
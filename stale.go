@@ -0,0 +1,96 @@
+package cachestore
+
+import "time"
+
+// withExpiry returns a copy of it that expires at t and is kept by GC until keepUntil
+func (it *item) withExpiry(t, keepUntil time.Time) *item {
+	n := &item{
+		tag:          it.tag,
+		data:         it.data,
+		createdAt:    it.createdAt,
+		expiresAt:    t,
+		version:      it.version,
+		loadDuration: it.loadDuration,
+		err:          it.err,
+		keepUntil:    keepUntil,
+		schema:       it.schema,
+		checksum:     it.checksum,
+		meta:         it.meta,
+		callers:      it.callers,
+		keyParts:     it.keyParts,
+		stale:        it.stale,
+	}
+	n.hits.Store(it.hits.Load())
+	n.lastAccess.Store(it.lastAccess.Load())
+	return n
+}
+
+// DeleteTagStale marks entries with tag as expired, they are still served by GetStale
+// until deleted after purgeAfter, giving loaders time to refill, GC does not remove them earlier.
+//
+// DeleteTagStale returns the number of marked entries.
+func DeleteTagStale(tag string, purgeAfter time.Duration) int {
+	if purgeAfter <= 0 {
+		return DeleteTag(tag)
+	}
+	checkTag(tag)
+
+	type entry struct {
+		m   engine
+		key string
+		it  *item
+	}
+
+	v := currentVersion()
+	now := time.Now()
+	var marked []entry
+	rangeItems(func(m engine, key string, it *item) bool {
+		if it.NewerThan(v) || it.tag != tag {
+			return true
+		}
+		n := it
+		if !it.Expired() {
+			n = it.withExpiry(now, now.Add(purgeAfter))
+			if !m.CompareAndSwap(key, it, n) {
+				return true
+			}
+		}
+		marked = append(marked, entry{m, key, n})
+		return true
+	})
+
+	afterFunc(purgeAfter, func() {
+		for _, x := range marked {
+			it, ok := deleteWhile(x.m, x.key, x.it, func(it *item) bool {
+				return it.version == x.it.version
+			})
+			if ok {
+				removed(x.key, it, EventDelete)
+			}
+		}
+	})
+	return len(marked)
+}
+
+// GetStaleWithin is GetStale that misses when key expired more than maxStale ago
+func GetStaleWithin[T any](key string, maxStale time.Duration) (T, bool) {
+	v, ok := getStaleWithin[T](key, maxStale)
+	audit(OpGetStale, key, ok, nil)
+	return v, ok
+}
+
+func getStaleWithin[T any](key string, maxStale time.Duration) (T, bool) {
+	if isDisabled() {
+		return *new(T), false
+	}
+
+	it, ok := loadItem(key)
+	if !ok {
+		return *new(T), false
+	}
+	if it.Expired() && time.Since(it.expiresAt) > maxStale {
+		return *new(T), false
+	}
+	it.checkImmutable(key)
+	return itemValue[T](it)
+}
@@ -0,0 +1,88 @@
+package cachestore
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Tag is an entry tag, in the form kind or kind:id
+type Tag = string
+
+// TagFor returns tag kind:id
+func TagFor(kind string, id any) Tag {
+	return kind + ":" + fmt.Sprint(id)
+}
+
+// entityKind returns the tag kind of T, its package path and name
+func entityKind[T any]() string {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Name() == "" {
+		return t.String()
+	}
+	return t.PkgPath() + "." + t.Name()
+}
+
+// TagEntity returns the tag of entity type T with id,
+// the kind is derived from the package path and name of T.
+func TagEntity[T any](id string) Tag {
+	return TagFor(entityKind[T](), id)
+}
+
+// InvalidateEntity deletes entries tagged with TagEntity[T](id) and returns the number of deleted entries
+func InvalidateEntity[T any](id string) int {
+	return DeleteTag(TagEntity[T](id))
+}
+
+// RegisterEntity registers the tag kind of T for strict mode
+func RegisterEntity[T any]() {
+	RegisterTag(entityKind[T]())
+}
+
+var (
+	tagKindsMu sync.RWMutex
+	tagKinds   = map[string]struct{}{}
+	strictTags uint32
+)
+
+// RegisterTag registers tag kinds allowed in strict mode
+func RegisterTag(kinds ...string) {
+	tagKindsMu.Lock()
+	defer tagKindsMu.Unlock()
+
+	for _, k := range kinds {
+		tagKinds[k] = struct{}{}
+	}
+}
+
+// SetStrictTags enables strict mode,
+// Set and DeleteTag panic when given a tag which kind is not registered.
+func SetStrictTags(value bool) {
+	if value {
+		atomic.StoreUint32(&strictTags, 1)
+	} else {
+		atomic.StoreUint32(&strictTags, 0)
+	}
+}
+
+func isStrictTags() bool {
+	return atomic.LoadUint32(&strictTags) == 1
+}
+
+func checkTag(tag string) {
+	if tag == "" || !isStrictTags() {
+		return
+	}
+
+	kind, _, _ := strings.Cut(tag, ":")
+
+	tagKindsMu.RLock()
+	_, ok := tagKinds[kind]
+	tagKindsMu.RUnlock()
+
+	if !ok {
+		panic(fmt.Sprintf("cachestore: unregistered tag %q", tag))
+	}
+}
@@ -0,0 +1,146 @@
+package cachestore
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+type TagConfig struct {
+	// MaxEntries is the number of entries tag may hold, zero means no limit
+	MaxEntries int
+
+	// RejectNew rejects new keys when tag is full instead of deleting its oldest entry
+	RejectNew bool
+
+	// OnLimit is called with the key deleted or rejected because tag was full
+	OnLimit func(tag, key string)
+}
+
+type tagLimiter struct {
+	tag string
+	cfg TagConfig
+
+	mu    sync.Mutex
+	order *list.List // of *tagEntry, oldest first
+	keys  map[string]*list.Element
+}
+
+// tagEntry is matched with the store by version, copies of an entry with a new expiry keep its version
+type tagEntry struct {
+	key     string
+	version uint64
+}
+
+var (
+	tagLimitsMu sync.RWMutex
+	tagLimits   = map[string]*tagLimiter{}
+	hasTagLimit uint32
+)
+
+// ConfigureTag limits the number of entries with tag,
+// entries set before ConfigureTag are not counted.
+func ConfigureTag(tag string, cfg TagConfig) {
+	tagLimitsMu.Lock()
+	defer tagLimitsMu.Unlock()
+
+	if cfg.MaxEntries <= 0 {
+		delete(tagLimits, tag)
+	} else {
+		tagLimits[tag] = &tagLimiter{
+			tag:   tag,
+			cfg:   cfg,
+			order: list.New(),
+			keys:  map[string]*list.Element{},
+		}
+	}
+	if len(tagLimits) > 0 {
+		atomic.StoreUint32(&hasTagLimit, 1)
+	} else {
+		atomic.StoreUint32(&hasTagLimit, 0)
+	}
+}
+
+func tagLimiterFor(tag string) *tagLimiter {
+	if atomic.LoadUint32(&hasTagLimit) == 0 {
+		return nil
+	}
+
+	tagLimitsMu.RLock()
+	defer tagLimitsMu.RUnlock()
+
+	return tagLimits[tag]
+}
+
+// prune forgets entries no longer in the store, l.mu must be held
+func (l *tagLimiter) prune() {
+	for e := l.order.Front(); e != nil; {
+		next := e.Next()
+		te := e.Value.(*tagEntry)
+		if _, it, ok := loadItemMap(te.key); !ok || it.version != te.version {
+			l.order.Remove(e)
+			delete(l.keys, te.key)
+		}
+		e = next
+	}
+}
+
+// admit reports whether normalized key can be stored
+func (l *tagLimiter) admit(key string) bool {
+	if !l.cfg.RejectNew {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.keys[key]; ok {
+		return true
+	}
+	if l.order.Len() < l.cfg.MaxEntries {
+		return true
+	}
+	l.prune()
+	return l.order.Len() < l.cfg.MaxEntries
+}
+
+// add records it stored for normalized key and deletes the oldest entries over the limit
+func (l *tagLimiter) add(key string, it *item) {
+	var deleted []string
+
+	l.mu.Lock()
+	if e, ok := l.keys[key]; ok {
+		e.Value.(*tagEntry).version = it.version
+		l.order.MoveToBack(e)
+	} else {
+		l.keys[key] = l.order.PushBack(&tagEntry{key: key, version: it.version})
+	}
+	if l.order.Len() > l.cfg.MaxEntries {
+		l.prune()
+	}
+	for l.order.Len() > l.cfg.MaxEntries {
+		e := l.order.Front()
+		te := e.Value.(*tagEntry)
+		l.order.Remove(e)
+		delete(l.keys, te.key)
+		m, cur, ok := loadItemMap(te.key)
+		if !ok {
+			continue
+		}
+		if cur, ok = deleteWhile(m, te.key, cur, func(it *item) bool { return it.version == te.version }); ok {
+			removed(te.key, cur, EventEvict)
+			deleted = append(deleted, te.key)
+		}
+	}
+	l.mu.Unlock()
+
+	for _, key := range deleted {
+		l.limited(key)
+	}
+}
+
+func (l *tagLimiter) limited(key string) {
+	if l.cfg.OnLimit != nil {
+		l.cfg.OnLimit(l.tag, key)
+	}
+}
@@ -0,0 +1,32 @@
+package cachestore
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestTagLimitAfterTouch(t *testing.T) {
+	tag := t.Name()
+	ConfigureTag(tag, TagConfig{MaxEntries: 2})
+	t.Cleanup(func() {
+		ConfigureTag(tag, TagConfig{})
+		DeleteTag(tag)
+	})
+
+	for i := 0; i < 10; i++ {
+		key := tag + "/" + strconv.Itoa(i)
+		Set(key, i, WithTag(tag), WithTTL(time.Hour))
+		GetAndTouch[int](key, time.Hour)
+	}
+
+	n := 0
+	for i := 0; i < 10; i++ {
+		if _, ok := Get[int](tag + "/" + strconv.Itoa(i)); ok {
+			n++
+		}
+	}
+	if n != 2 {
+		t.Fatalf("got %d live entries, want 2", n)
+	}
+}
@@ -0,0 +1,99 @@
+package cachestore
+
+import "time"
+
+// GetAndTouch returns the value for key and sets its expiry to ttl from now,
+// zero ttl removes the expiry. It is a Get to interceptors, the audit log and hit rates.
+func GetAndTouch[T any](key string, ttl time.Duration) (T, bool) {
+	if hasInterceptors() {
+		v, ok := intercept(&Call{Op: OpGet, Key: key}, func(c *Call) (any, bool) {
+			return observedGetAndTouch[T](c.Key, ttl)
+		})
+		t, match := v.(T)
+		return t, ok && (match || v == nil)
+	}
+	return observedGetAndTouch[T](key, ttl)
+}
+
+func observedGetAndTouch[T any](key string, ttl time.Duration) (T, bool) {
+	v, ok := getAndTouch[T](key, ttl)
+	audit(OpGet, key, ok, nil)
+	trackHit(key, ok)
+	return v, ok
+}
+
+func getAndTouch[T any](key string, ttl time.Duration) (T, bool) {
+	if isDisabled() {
+		return *new(T), false
+	}
+
+	it, ok := touch(key, ttl)
+	if !ok {
+		return *new(T), false
+	}
+	it.checkImmutable(key)
+	return itemValue[T](it)
+}
+
+// touch sets expiry of key to ttl from now and returns the new entry
+func touch(key string, ttl time.Duration) (*item, bool) {
+	key = normalizeKey(key)
+	for {
+		m, it, ok := loadItemMap(key)
+		if !ok || it.Expired() || it.err != nil {
+			return nil, false
+		}
+		var expiresAt, keepUntil time.Time
+		if ttl > 0 {
+			expiresAt = time.Now().Add(ttl)
+			if !it.keepUntil.IsZero() { // keep the stale window, see WithMaxServeStale
+				keepUntil = expiresAt.Add(it.keepUntil.Sub(it.expiresAt))
+			}
+		}
+		n := it.withExpiry(expiresAt, keepUntil)
+		if m.CompareAndSwap(key, it, n) {
+			return n, true
+		}
+	}
+}
+
+// MTouch sets expiry of keys to ttl from now and returns the number of touched keys,
+// zero ttl removes the expiry.
+func MTouch(keys []string, ttl time.Duration) int {
+	if isDisabled() {
+		return 0
+	}
+
+	n := 0
+	for _, k := range keys {
+		if _, ok := touch(k, ttl); ok {
+			n++
+		}
+	}
+	return n
+}
+
+// MTTL returns the remaining TTL of each cached key, zero for keys without expiry,
+// missing and expired keys are not in the result.
+func MTTL(keys []string) map[string]time.Duration {
+	m := make(map[string]time.Duration, len(keys))
+	if isDisabled() {
+		return m
+	}
+
+	now := time.Now()
+	for _, k := range keys {
+		it, ok := loadItem(k)
+		if !ok || it.Expired() || it.err != nil {
+			continue
+		}
+		if it.expiresAt.IsZero() {
+			m[k] = 0
+			continue
+		}
+		if d := it.expiresAt.Sub(now); d > 0 {
+			m[k] = d
+		}
+	}
+	return m
+}
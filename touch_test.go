@@ -0,0 +1,34 @@
+package cachestore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetAndTouchObserved(t *testing.T) {
+	SetAuditLog(16)
+	defer SetAuditLog(0)
+	key := t.Name()
+	defer Delete(key)
+	Set(key, 1)
+
+	var seen []string
+	Use(InterceptorFunc(func(c *Call, next Invoker) (any, bool) {
+		if c.Op == OpGet {
+			seen = append(seen, c.Key)
+		}
+		return next(c)
+	}))
+	defer interceptors.Store(nil)
+
+	if v, ok := GetAndTouch[int](key, time.Minute); !ok || v != 1 {
+		t.Fatalf("GetAndTouch = %v, %v", v, ok)
+	}
+	if len(seen) != 1 || seen[0] != key {
+		t.Fatalf("interceptor saw %v, want one get of %s", seen, key)
+	}
+	log := AuditLog()
+	if len(log) == 0 || log[len(log)-1].Op != OpGet || log[len(log)-1].Key != key || !log[len(log)-1].Hit {
+		t.Fatalf("audit log = %+v, want a get hit of %s last", log, key)
+	}
+}
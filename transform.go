@@ -0,0 +1,42 @@
+package cachestore
+
+import "sync/atomic"
+
+type transform struct {
+	onStore func(any) (any, error)
+	onLoad  func(any) (any, error)
+}
+
+var transformer atomic.Pointer[transform]
+
+// SetTransform sets functions applied to every value before it is stored and after it is loaded,
+// a nil function leaves values as is.
+//
+// When onStore returns an error the value is not stored and the key is deleted,
+// when onLoad returns an error the entry is treated as missing.
+func SetTransform(onStore, onLoad func(any) (any, error)) {
+	if onStore == nil && onLoad == nil {
+		transformer.Store(nil)
+		return
+	}
+	transformer.Store(&transform{
+		onStore: onStore,
+		onLoad:  onLoad,
+	})
+}
+
+func storeTransform(value any) (any, error) {
+	t := transformer.Load()
+	if t == nil || t.onStore == nil {
+		return value, nil
+	}
+	return t.onStore(value)
+}
+
+func loadTransform(value any) (any, error) {
+	t := transformer.Load()
+	if t == nil || t.onLoad == nil {
+		return value, nil
+	}
+	return t.onLoad(value)
+}
@@ -0,0 +1,93 @@
+package cachestore
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type registeredType struct {
+	name       string
+	version    int
+	decode     func(b json.RawMessage) (any, error)
+	migrations map[int]func(old json.RawMessage) (json.RawMessage, error) // from version
+}
+
+var (
+	typesMu     sync.RWMutex
+	typesByName = map[string]*registeredType{}
+	typeNames   = map[reflect.Type]*registeredType{}
+)
+
+// RegisterType registers T under name at version,
+// StartRecording stores the name and version with values of T so Replay with nil decode can decode them.
+func RegisterType[T any](name string, version int) {
+	typesMu.Lock()
+	defer typesMu.Unlock()
+
+	t := &registeredType{
+		name:    name,
+		version: version,
+		decode: func(b json.RawMessage) (any, error) {
+			var v T
+			err := json.Unmarshal(b, &v)
+			return v, err
+		},
+		migrations: map[int]func(json.RawMessage) (json.RawMessage, error){},
+	}
+	if old, ok := typesByName[name]; ok {
+		t.migrations = old.migrations
+	}
+	typesByName[name] = t
+	typeNames[reflect.TypeOf((*T)(nil)).Elem()] = t
+}
+
+// RegisterTypeMigration registers fn converting a value of type name recorded at version from to version from+1
+func RegisterTypeMigration(name string, from int, fn func(old json.RawMessage) (json.RawMessage, error)) {
+	typesMu.Lock()
+	defer typesMu.Unlock()
+
+	t, ok := typesByName[name]
+	if !ok {
+		t = &registeredType{name: name, migrations: map[int]func(json.RawMessage) (json.RawMessage, error){}}
+		typesByName[name] = t
+	}
+	t.migrations[from] = fn
+}
+
+func registeredTypeOf(v any) (string, int, bool) {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+
+	t, ok := typeNames[reflect.TypeOf(v)]
+	if !ok {
+		return "", 0, false
+	}
+	return t.name, t.version, true
+}
+
+// decodeRegistered decodes b recorded as type name at version, migrating it to the registered version
+func decodeRegistered(name string, version int, b json.RawMessage) (any, error) {
+	typesMu.RLock()
+	t, ok := typesByName[name]
+	typesMu.RUnlock()
+	if !ok || t.decode == nil {
+		return nil, fmt.Errorf("cachestore: unregistered type %q", name)
+	}
+
+	for v := version; v < t.version; v++ {
+		fn, ok := t.migrations[v]
+		if !ok {
+			return nil, fmt.Errorf("cachestore: no migration of %q from version %d", name, v)
+		}
+		var err error
+		if b, err = fn(b); err != nil {
+			return nil, err
+		}
+	}
+	if version > t.version {
+		return nil, fmt.Errorf("cachestore: %q version %d is newer than %d", name, version, t.version)
+	}
+	return t.decode(b)
+}
@@ -0,0 +1,96 @@
+package cachestore
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync/atomic"
+)
+
+type validateHook struct {
+	fn   func(err error)
+	done atomic.Bool
+}
+
+var validateOnSet atomic.Pointer[validateHook]
+
+// SetValidateOnFirstSet makes the first Set after the call run Validate and pass its error to fn,
+// fn is not called when Validate returns nil. nil fn disables the check.
+func SetValidateOnFirstSet(fn func(err error)) {
+	if fn == nil {
+		validateOnSet.Store(nil)
+		return
+	}
+	validateOnSet.Store(&validateHook{fn: fn})
+}
+
+// Validate reports settings that conflict or can not work as configured, see SetValidateOnFirstSet
+func Validate() error {
+	var errs []error
+
+	if isStrictTags() {
+		tagKindsMu.RLock()
+		n := len(tagKinds)
+		tagKindsMu.RUnlock()
+		if n == 0 {
+			errs = append(errs, errors.New("cachestore: strict tags enabled without registered tag kinds, call RegisterTag or RegisterEntity"))
+		}
+
+		tagLimitsMu.RLock()
+		for tag := range tagLimits {
+			kind, _, _ := strings.Cut(tag, ":")
+			tagKindsMu.RLock()
+			_, ok := tagKinds[kind]
+			tagKindsMu.RUnlock()
+			if !ok {
+				errs = append(errs, fmt.Errorf("cachestore: ConfigureTag for unregistered tag %q in strict mode, call RegisterTag(%q)", tag, kind))
+			}
+		}
+		tagLimitsMu.RUnlock()
+	}
+
+	samplingMu.RLock()
+	for _, r := range samplingRules {
+		if _, err := path.Match(r.pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("cachestore: SetSampling pattern %q: %w", r.pattern, err))
+		}
+		if r.fraction < 0 {
+			errs = append(errs, fmt.Errorf("cachestore: SetSampling fraction %v for %q is negative, use 0 to always bypass", r.fraction, r.pattern))
+		}
+	}
+	samplingMu.RUnlock()
+
+	if coldSlots.Load() != nil {
+		warmKeysMu.RLock()
+		n := len(warmKeys)
+		warmKeysMu.RUnlock()
+		if n == 0 {
+			errs = append(errs, errors.New("cachestore: SetColdStart without SetWarmKeys never ends, call SetWarmKeys or EndColdStart"))
+		}
+	}
+
+	if !GCStatus().Running {
+		expiring := false
+		rangeItems(func(_ engine, _ string, it *item) bool {
+			expiring = !it.expiresAt.IsZero()
+			return !expiring
+		})
+		if expiring {
+			errs = append(errs, errors.New("cachestore: entries expire but no GC loop is running, expired entries are never removed, call RunGCInterval or set Config.GCInterval"))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateOnFirstSet reports the error of Validate to the hook once, on the first Set after it was set
+func validateOnFirstSet() {
+	h := validateOnSet.Load()
+	if h == nil || h.done.Load() || !h.done.CompareAndSwap(false, true) {
+		return
+	}
+	if err := Validate(); err != nil {
+		h.fn(err)
+	}
+}
@@ -0,0 +1,19 @@
+package cachestore
+
+import "testing"
+
+func TestValidateOnFirstSet(t *testing.T) {
+	SetColdStart(1, false)
+	defer EndColdStart()
+
+	var errs []error
+	SetValidateOnFirstSet(func(err error) { errs = append(errs, err) })
+	defer SetValidateOnFirstSet(nil)
+
+	Set("validate-a", 1)
+	Set("validate-b", 2)
+	defer DeleteKeys("validate-a", "validate-b")
+	if len(errs) != 1 {
+		t.Fatalf("reported %d errors, want 1 on the first Set", len(errs))
+	}
+}
@@ -0,0 +1,101 @@
+package cachestore
+
+import "sort"
+
+// View is an immutable copy of the cache, see SnapshotView
+type View struct {
+	keys     []string // sorted
+	items    map[string]any
+	versions map[string]uint64
+}
+
+// SnapshotView returns a View of all entries not expired while it copies them,
+// later writes to the cache do not affect the view.
+//
+// The view is consistent with Bulk commits only, it sees each commit as a whole.
+// Set and Delete outside a Bulk may land during the copy, so the view may hold
+// some writes made while it was taken and miss others.
+func SnapshotView() View {
+	bulkMu.RLock()
+	defer bulkMu.RUnlock()
+
+	items := make(map[string]any)
+	versions := make(map[string]uint64)
+	seen := make(map[string]struct{})
+	rangeItems(func(_ engine, key string, it *item) bool {
+		if _, ok := seen[key]; ok { // shadowed by current generation
+			return true
+		}
+		seen[key] = struct{}{}
+		if it.Expired() || it.err != nil || it.Outdated() {
+			return true
+		}
+		data, err := loadTransform(it.data)
+		if err != nil {
+			return true
+		}
+		items[key] = data
+		versions[key] = it.version
+		return true
+	})
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return View{keys: keys, items: items, versions: versions}
+}
+
+func (v View) Get(key string) (any, bool) {
+	data, ok := v.items[normalizeKey(key)]
+	return data, ok
+}
+
+func (v View) Len() int {
+	return len(v.items)
+}
+
+// Keys returns keys in the view in sorted order
+func (v View) Keys() []string {
+	return append([]string(nil), v.keys...)
+}
+
+// Range calls fn for each entry in the view in sorted key order until fn returns false
+func (v View) Range(fn func(key string, value any) bool) {
+	for _, k := range v.keys {
+		if !fn(k, v.items[k]) {
+			return
+		}
+	}
+}
+
+// Keys returns keys of all entries not expired in sorted order
+func Keys() []string {
+	return keysFunc(func(it *item) bool {
+		return true
+	})
+}
+
+// KeysByTag returns keys of entries with tag not expired in sorted order
+func KeysByTag(tag string) []string {
+	return keysFunc(func(it *item) bool {
+		return it.tag == tag
+	})
+}
+
+func keysFunc(fn func(it *item) bool) []string {
+	var keys []string
+	seen := make(map[string]struct{})
+	rangeItems(func(_ engine, key string, it *item) bool {
+		if _, ok := seen[key]; ok { // shadowed by current generation
+			return true
+		}
+		seen[key] = struct{}{}
+		if !it.Expired() && it.err == nil && !it.Outdated() && fn(it) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	sort.Strings(keys)
+	return keys
+}
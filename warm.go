@@ -0,0 +1,130 @@
+package cachestore
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var (
+	warmKeysMu sync.RWMutex
+	warmKeys   []string
+)
+
+// SetWarmKeys sets keys that must be cached for Ready to report true
+func SetWarmKeys(keys ...string) {
+	warmKeysMu.Lock()
+	defer warmKeysMu.Unlock()
+
+	warmKeys = append([]string(nil), keys...)
+}
+
+// Ready reports whether every key set by SetWarmKeys is cached and not expired
+func Ready() bool {
+	warmKeysMu.RLock()
+	defer warmKeysMu.RUnlock()
+
+	return cached(warmKeys)
+}
+
+func cached(keys []string) bool {
+	for _, k := range keys {
+		it, ok := loadItem(k)
+		if !ok || it.Expired() {
+			return false
+		}
+	}
+	return true
+}
+
+const warmPollInterval = 100 * time.Millisecond
+
+// WaitWarm waits until every key is cached and not expired,
+// or keys set by SetWarmKeys when no key is given.
+func WaitWarm(ctx context.Context, keys ...string) error {
+	ready := func() bool {
+		if len(keys) == 0 {
+			return Ready()
+		}
+		return cached(keys)
+	}
+
+	if ready() {
+		return nil
+	}
+	t := time.NewTicker(warmPollInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			if ready() {
+				return nil
+			}
+		}
+	}
+}
+
+// WarmReport is the result of Warm
+type WarmReport struct {
+	Loaded    int
+	Errors    map[string]error         // keys which loader failed
+	Durations map[string]time.Duration // time taken to load each key
+}
+
+// Warm loads keys that are not cached using loader with at most concurrency loaders at a time,
+// a failed key does not stop the other keys. Keys not started when ctx is done fail with ctx.Err().
+func Warm(ctx context.Context, keys []string, loader func(ctx context.Context, key string) (any, error), concurrency int, opts ...Option) WarmReport {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	r := WarmReport{
+		Errors:    map[string]error{},
+		Durations: map[string]time.Duration{},
+	}
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+	for _, key := range keys {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			r.Errors[key] = ctx.Err()
+			mu.Unlock()
+			continue
+		}
+
+		// when the goroutine cap is reached the key is loaded in the calling goroutine
+		wg.Add(1)
+		key := key
+		run := func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			start := time.Now()
+			_, err := GetOrSet(ctx, key, func(ctx context.Context) (any, error) {
+				return loader(ctx, key)
+			}, opts...)
+			d := time.Since(start)
+
+			mu.Lock()
+			defer mu.Unlock()
+			r.Durations[key] = d
+			if err != nil {
+				r.Errors[key] = err
+				return
+			}
+			r.Loaded++
+		}
+		if !spawn(run) {
+			run()
+		}
+	}
+	wg.Wait()
+	return r
+}
@@ -0,0 +1,84 @@
+package cachestore
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const windowBuckets = 3600 // one per second, covers the longest window
+
+type windowBucket struct {
+	sec   atomic.Int64
+	hits  atomic.Uint64
+	total atomic.Uint64
+}
+
+var (
+	trackWindows uint32
+	windows      [windowBuckets]windowBucket
+)
+
+// SetTrackHitWindows enables counting Get and GetOrSet hits per second for HitWindows
+func SetTrackHitWindows(value bool) {
+	if value {
+		atomic.StoreUint32(&trackWindows, 1)
+	} else {
+		atomic.StoreUint32(&trackWindows, 0)
+	}
+}
+
+func recordWindow(hit bool) {
+	if atomic.LoadUint32(&trackWindows) == 0 {
+		return
+	}
+
+	sec := time.Now().Unix()
+	b := &windows[sec%windowBuckets]
+	if old := b.sec.Load(); old != sec && b.sec.CompareAndSwap(old, sec) {
+		b.hits.Store(0)
+		b.total.Store(0)
+	}
+	b.total.Add(1)
+	if hit {
+		b.hits.Add(1)
+	}
+}
+
+type HitWindowStats struct {
+	Minute      float64
+	FiveMinutes float64
+	Hour        float64
+}
+
+// HitWindows returns hit ratios of Get and GetOrSet over the last minute, five minutes and hour,
+// a window without calls has ratio zero.
+func HitWindows() HitWindowStats {
+	now := time.Now().Unix()
+	var hits, total [3]uint64
+	limits := [3]int64{60, 300, 3600}
+	for i := range windows {
+		b := &windows[i]
+		age := now - b.sec.Load()
+		if age < 0 || age >= windowBuckets {
+			continue
+		}
+		h, t := b.hits.Load(), b.total.Load()
+		for j, limit := range limits {
+			if age < limit {
+				hits[j] += h
+				total[j] += t
+			}
+		}
+	}
+	ratio := func(i int) float64 {
+		if total[i] == 0 {
+			return 0
+		}
+		return float64(hits[i]) / float64(total[i])
+	}
+	return HitWindowStats{
+		Minute:      ratio(0),
+		FiveMinutes: ratio(1),
+		Hour:        ratio(2),
+	}
+}